@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+type AtomFeed struct {
+	XMLName  xml.Name    `xml:"feed"`
+	ID       string      `xml:"id"`
+	Title    AtomText    `xml:"title"`
+	Subtitle AtomText    `xml:"subtitle"`
+	Updated  string      `xml:"updated"`
+	Links    []AtomLink  `xml:"link"`
+	Entries  []AtomEntry `xml:"entry"`
+}
+
+// AtomEntry qualifies title and content with the Atom namespace so they
+// do not also pick up <media:title> and <media:content>.
+type AtomEntry struct {
+	ID         string         `xml:"id"`
+	Title      AtomText       `xml:"http://www.w3.org/2005/Atom title"`
+	Links      []AtomLink     `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Summary    AtomText       `xml:"summary"`
+	Content    AtomText       `xml:"http://www.w3.org/2005/Atom content"`
+	Authors    []AtomPerson   `xml:"author"`
+	Categories []AtomCategory `xml:"category"`
+	MediaElements
+}
+
+type AtomPerson struct {
+	Name  string `xml:"name"`
+	Email string `xml:"email"`
+}
+
+// AtomCategory carries its machine-readable term and an optional
+// human-readable label as attributes.
+type AtomCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr"`
+}
+
+// AtomText is an Atom text construct. xhtml content is kept as markup,
+// text and html content arrive as character data.
+type AtomText struct {
+	Type     string `xml:"type,attr"`
+	Body     string `xml:",chardata"`
+	InnerXML string `xml:",innerxml"`
+}
+
+func (text AtomText) String() string {
+	if text.Type == "xhtml" {
+		return strings.TrimSpace(text.InnerXML)
+	}
+	return strings.TrimSpace(text.Body)
+}
+
+// alternateLink picks the rel="alternate" link, which is also the default
+// when rel is omitted, falling back to the first link present.
+func alternateLink(links []AtomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link.Href
+		}
+	}
+	if len(links) > 0 {
+		return links[0].Href
+	}
+	return ""
+}
+
+// toRSS maps an Atom feed onto the RSS model so it can share the post
+// ingestion path in processFeeds.
+func (feed *AtomFeed) toRSS() RSS {
+	rss := RSS{
+		Channel: Channel{
+			Title:         feed.Title.String(),
+			Link:          alternateLink(feed.Links),
+			Description:   feed.Subtitle.String(),
+			LastBuildDate: feed.Updated,
+			AtomLinks:     feed.Links,
+			Items:         make([]Item, len(feed.Entries)),
+		},
+	}
+	for i, entry := range feed.Entries {
+		item := &rss.Channel.Items[i]
+		item.Title = entry.Title.String()
+		item.Link = alternateLink(entry.Links)
+		item.GUID = entry.ID
+		item.MediaElements = entry.MediaElements
+		item.PubDate = entry.Published
+		if item.PubDate == "" {
+			item.PubDate = entry.Updated
+		}
+		item.Description = entry.Summary.String()
+		item.Content = entry.Content.String()
+		if item.Description == "" {
+			item.Description = item.Content
+		}
+		names := make([]string, 0, len(entry.Authors))
+		for _, author := range entry.Authors {
+			if name := strings.TrimSpace(author.Name); name != "" {
+				names = append(names, name)
+			}
+		}
+		item.Authors = names
+		item.Author = strings.Join(names, ", ")
+		for _, category := range entry.Categories {
+			term := category.Term
+			if term == "" {
+				term = category.Label
+			}
+			item.Categories = append(item.Categories, term)
+		}
+		for _, link := range entry.Links {
+			if link.Rel == "enclosure" {
+				item.Enclosures = append(item.Enclosures, Enclosure{URL: link.Href, Length: link.Length, Type: link.Type})
+			}
+		}
+	}
+	return rss
+}
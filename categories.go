@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/rowinf/blog-aggregator/internal/database"
+)
+
+// rssAuthor matches the RSS 2.0 author form, an email address followed by
+// the person's name in parentheses.
+var rssAuthor = regexp.MustCompile(`^\S+@\S+\s+\((.+)\)$`)
+
+// authorNames lists the item's authors as the feed names them: its own
+// list when the format has one, else the RSS author, reduced to the name
+// when it is given as "email (Name)", else every dc:creator. A single name
+// is never split, as names like "Doe, Jane" contain commas.
+func authorNames(item Item) []string {
+	if len(item.Authors) > 0 {
+		return item.Authors
+	}
+	author := strings.TrimSpace(item.Author)
+	if match := rssAuthor.FindStringSubmatch(author); match != nil {
+		author = strings.TrimSpace(match[1])
+	}
+	if author != "" {
+		return []string{author}
+	}
+	names := make([]string, 0, len(item.Creators))
+	for _, creator := range item.Creators {
+		if creator = strings.TrimSpace(creator); creator != "" {
+			names = append(names, creator)
+		}
+	}
+	return names
+}
+
+// postAuthor is the byline stored with a post: its authors' names joined.
+func postAuthor(item Item) string {
+	return strings.Join(authorNames(item), ", ")
+}
+
+// postAuthors returns the normalized names of the item's authors without
+// duplicates, for filtering posts by any one of them.
+func postAuthors(item Item) []string {
+	names := authorNames(item)
+	seen := make(map[string]bool, len(names))
+	authors := make([]string, 0, len(names))
+	for _, name := range names {
+		name = normalizeAuthor(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		authors = append(authors, name)
+	}
+	return authors
+}
+
+// normalizeAuthor puts an author name in the form it is stored and
+// filtered by, the same as categories.
+func normalizeAuthor(author string) string {
+	return normalizeCategory(author)
+}
+
+// postCategories returns the item's categories normalized and without
+// duplicates, in the order the feed lists them.
+func postCategories(item Item) []string {
+	seen := make(map[string]bool, len(item.Categories))
+	categories := make([]string, 0, len(item.Categories))
+	for _, category := range item.Categories {
+		name := normalizeCategory(category)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		categories = append(categories, name)
+	}
+	return categories
+}
+
+// normalizeCategory lowercases a category and collapses its whitespace so
+// "Go", " go " and "GO" are stored and filtered as the same category.
+func normalizeCategory(category string) string {
+	return strings.ToLower(strings.Join(strings.Fields(category), " "))
+}
+
+// savePostCategories replaces the stored categories of a post with the ones
+// the feed currently lists.
+func (cfg *ApiConfig) savePostCategories(ctx context.Context, feedID, guid string, categories []string) error {
+	err := cfg.DB.DeleteStalePostCategories(ctx, database.DeleteStalePostCategoriesParams{
+		FeedID: feedID,
+		Guid:   guid,
+		Names:  categories,
+	})
+	if err != nil {
+		return err
+	}
+	if len(categories) == 0 {
+		return nil
+	}
+	return cfg.DB.AddPostCategories(ctx, database.AddPostCategoriesParams{
+		Names:  categories,
+		FeedID: feedID,
+		Guid:   guid,
+	})
+}
+
+// savePostAuthors replaces the stored authors of a post with the ones the
+// feed currently lists.
+func (cfg *ApiConfig) savePostAuthors(ctx context.Context, feedID, guid string, authors []string) error {
+	err := cfg.DB.DeleteStalePostAuthors(ctx, database.DeleteStalePostAuthorsParams{
+		FeedID: feedID,
+		Guid:   guid,
+		Names:  authors,
+	})
+	if err != nil {
+		return err
+	}
+	if len(authors) == 0 {
+		return nil
+	}
+	return cfg.DB.AddPostAuthors(ctx, database.AddPostAuthorsParams{
+		Names:  authors,
+		FeedID: feedID,
+		Guid:   guid,
+	})
+}
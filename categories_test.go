@@ -0,0 +1,64 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPostAuthor(t *testing.T) {
+	cases := []struct {
+		item Item
+		want string
+	}{
+		{Item{Author: "jane@example.com (Jane Doe)"}, "Jane Doe"},
+		{Item{Author: "jane@example.com"}, "jane@example.com"},
+		{Item{Author: " Ada ", Creators: []string{"Grace"}}, "Ada"},
+		{Item{Creators: []string{" Grace Hopper "}}, "Grace Hopper"},
+		{Item{Creators: []string{"Ada", "", "Grace"}}, "Ada, Grace"},
+		{Item{}, ""},
+	}
+	for _, c := range cases {
+		if got := postAuthor(c.item); got != c.want {
+			t.Errorf("postAuthor(%+v) = %q, want %q", c.item, got, c.want)
+		}
+	}
+}
+
+func TestPostAuthors(t *testing.T) {
+	cases := []struct {
+		item Item
+		want []string
+	}{
+		{Item{Authors: []string{"Ada", " Grace  Hopper", "ada"}, Author: "Ada, Grace  Hopper, ada"}, []string{"ada", "grace hopper"}},
+		{Item{Author: "Doe, Jane"}, []string{"doe, jane"}},
+		{Item{Author: "jane@example.com (Jane Doe)"}, []string{"jane doe"}},
+		{Item{Creators: []string{"Ada", "Doe, Jane", "ada"}}, []string{"ada", "doe, jane"}},
+		{Item{}, []string{}},
+	}
+	for _, c := range cases {
+		if got := postAuthors(c.item); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("postAuthors(%+v) = %q, want %q", c.item, got, c.want)
+		}
+	}
+
+	body := `{"version": "https://jsonfeed.org/version/1.1", "items": [
+  {"id": "1", "authors": [{"name": "Ada"}, {"name": "Grace"}]}]}`
+	rss, err := ParseFeed("application/feed+json", []byte(body))
+	if err != nil {
+		t.Fatalf("ParseFeed returned an error: %v", err)
+	}
+	if got := postAuthors(rss.Channel.Items[0]); !reflect.DeepEqual(got, []string{"ada", "grace"}) {
+		t.Errorf("postAuthors of a JSON Feed item = %q, want each author on its own", got)
+	}
+}
+
+func TestPostCategories(t *testing.T) {
+	item := Item{Categories: []string{"Go", " go ", "Web  Development", "", "GO", "databases"}}
+	want := []string{"go", "web development", "databases"}
+	if got := postCategories(item); !reflect.DeepEqual(got, want) {
+		t.Errorf("postCategories = %q, want %q", got, want)
+	}
+	if got := postCategories(Item{}); got == nil || len(got) != 0 {
+		t.Errorf("postCategories(no categories) = %#v, want an empty, non-nil slice", got)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+
+	"golang.org/x/net/html/charset"
+)
+
+// knownCharset reports whether label names a charset decodeCharset can
+// read: any encoding label from the WHATWG Encoding Standard.
+func knownCharset(label string) bool {
+	encoding, _ := charset.Lookup(label)
+	return encoding != nil
+}
+
+// decodeCharset transcodes body from the named charset to UTF-8. Like
+// browsers, it reads ISO-8859-1 and US-ASCII as their Windows-1252
+// superset, since feeds declaring them routinely contain curly quotes and
+// dashes from the 0x80-0x9F range.
+func decodeCharset(label string, body []byte) ([]byte, error) {
+	reader, err := charset.NewReaderLabel(label, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(reader)
+}
+
+// newFeedDecoder returns an XML decoder that reads body as UTF-8. A charset
+// from the HTTP Content-Type takes precedence over the XML declaration;
+// once the body has been transcoded with it, the declared encoding is
+// ignored. Without one the declaration picks the charset.
+func newFeedDecoder(body []byte, httpCharset string) (*xml.Decoder, error) {
+	reader := charset.NewReaderLabel
+	if httpCharset != "" {
+		decoded, err := decodeCharset(httpCharset, body)
+		if err != nil {
+			return nil, err
+		}
+		body = decoded
+		reader = func(_ string, input io.Reader) (io.Reader, error) {
+			return input, nil
+		}
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.CharsetReader = reader
+	return decoder, nil
+}
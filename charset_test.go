@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+)
+
+// latin1Feed builds an RSS document whose item title is "Café – “menu”"
+// encoded as Windows-1252, declaring the given encoding.
+func latin1Feed(declaration string) []byte {
+	head := `<?xml version="1.0"` + declaration + `?><rss version="2.0"><channel><title>Caf`
+	body := []byte(head)
+	body = append(body, 0xe9)
+	body = append(body, []byte(`</title><item><title>Caf`)...)
+	body = append(body, 0xe9, ' ', 0x96, ' ', 0x93)
+	body = append(body, []byte(`menu`)...)
+	body = append(body, 0x94)
+	body = append(body, []byte(`</title></item></channel></rss>`)...)
+	return body
+}
+
+func TestParseFeedDeclaredCharset(t *testing.T) {
+	for _, encoding := range []string{"ISO-8859-1", "windows-1252", "latin1", "US-ASCII"} {
+		rss, err := ParseFeed("application/rss+xml", latin1Feed(` encoding="`+encoding+`"`))
+		if err != nil {
+			t.Fatalf("ParseFeed(%s) returned an error: %v", encoding, err)
+		}
+		if rss.Channel.Title != "Café" {
+			t.Errorf("%s channel title = %q, want %q", encoding, rss.Channel.Title, "Café")
+		}
+		if got := rss.Channel.Items[0].Title; got != "Café – “menu”" {
+			t.Errorf("%s item title = %q", encoding, got)
+		}
+	}
+}
+
+func TestParseFeedHTTPCharset(t *testing.T) {
+	// The Content-Type charset wins over a missing or wrong declaration.
+	for _, declaration := range []string{"", ` encoding="utf-8"`, ` encoding="ISO-8859-1"`} {
+		rss, err := ParseFeed("text/xml; charset=windows-1252", latin1Feed(declaration))
+		if err != nil {
+			t.Fatalf("ParseFeed(%q) returned an error: %v", declaration, err)
+		}
+		if rss.Channel.Title != "Café" {
+			t.Errorf("declaration %q: title = %q, want %q", declaration, rss.Channel.Title, "Café")
+		}
+	}
+
+	// A charset we do not know defers to the XML declaration.
+	rss, err := ParseFeed(`text/xml; charset="x-unknown"`, latin1Feed(` encoding="ISO-8859-1"`))
+	if err != nil {
+		t.Fatalf("ParseFeed returned an error: %v", err)
+	}
+	if rss.Channel.Title != "Café" {
+		t.Errorf("title = %q, want %q", rss.Channel.Title, "Café")
+	}
+}
+
+func TestParseFeedUnsupportedCharset(t *testing.T) {
+	if _, err := ParseFeed("", latin1Feed(` encoding="x-klingon"`)); err == nil {
+		t.Error("ParseFeed should reject a declared charset it cannot decode")
+	}
+}
+
+func TestDecodeCharset(t *testing.T) {
+	got, err := decodeCharset("UTF-8", []byte("naïve"))
+	if err != nil || string(got) != "naïve" {
+		t.Errorf("decodeCharset(utf-8) = %q, %v", got, err)
+	}
+	got, err = decodeCharset("cp1252", []byte{0x80, 0x9f, 0xff})
+	if err != nil || string(got) != "€Ÿÿ" {
+		t.Errorf("decodeCharset(cp1252) = %q, %v", got, err)
+	}
+}
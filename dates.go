@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// dateLayouts are tried in order after normalizeDate has dropped any
+// leading weekday and replaced named zones with numeric offsets. Layouts
+// without a zone are read as UTC.
+var dateLayouts = []string{
+	// RFC 822 / RFC 1123 and the usual deviations from them
+	"2 Jan 2006 15:04:05 Z0700",
+	"2 Jan 2006 15:04:05 Z07:00",
+	"2 Jan 2006 15:04 Z0700",
+	"2 Jan 2006 15:04 Z07:00",
+	"2 Jan 06 15:04:05 Z0700",
+	"2 Jan 06 15:04 Z0700",
+	"2 January 2006 15:04:05 Z0700",
+	"2 January 2006 15:04 Z0700",
+	"2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04 MST",
+	"2 Jan 06 15:04:05 MST",
+	"2 Jan 2006 15:04:05",
+	"2 Jan 2006",
+	"2-Jan-06 15:04:05 Z0700",
+	"2-Jan-2006 15:04:05 Z0700",
+	// RFC 3339 / ISO 8601
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 Z0700",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	// C and Unix style
+	time.ANSIC,
+	time.UnixDate,
+	time.RubyDate,
+}
+
+// zoneOffsets resolves the zone abbreviations feeds use in practice. Go's
+// parser would otherwise read an unknown abbreviation as UTC.
+var zoneOffsets = map[string]string{
+	"UT":   "+0000",
+	"UTC":  "+0000",
+	"GMT":  "+0000",
+	"Z":    "+0000",
+	"EST":  "-0500",
+	"EDT":  "-0400",
+	"CST":  "-0600",
+	"CDT":  "-0500",
+	"MST":  "-0700",
+	"MDT":  "-0600",
+	"PST":  "-0800",
+	"PDT":  "-0700",
+	"AKST": "-0900",
+	"AKDT": "-0800",
+	"HST":  "-1000",
+	"BST":  "+0100",
+	"WET":  "+0000",
+	"WEST": "+0100",
+	"CET":  "+0100",
+	"CEST": "+0200",
+	"EET":  "+0200",
+	"EEST": "+0300",
+	"MSK":  "+0300",
+	"IST":  "+0530",
+	"JST":  "+0900",
+	"KST":  "+0900",
+	"AEST": "+1000",
+	"AEDT": "+1100",
+	"NZST": "+1200",
+	"NZDT": "+1300",
+}
+
+var (
+	leadingWeekday  = regexp.MustCompile(`^[A-Za-z]+\.?,?\s+(\d)`)
+	trailingComment = regexp.MustCompile(`\s*\([^)]*\)$`)
+)
+
+var errEmptyDate = errors.New("empty date")
+
+// ParseDate parses the timestamp formats found in RSS, Atom, RDF and JSON
+// feeds, including RFC 822 with two digit years or missing seconds, named
+// zones and ISO 8601 variants.
+func ParseDate(dateStr string) (time.Time, error) {
+	normalized := normalizeDate(dateStr)
+	if normalized == "" {
+		return time.Time{}, errEmptyDate
+	}
+	for _, layout := range dateLayouts {
+		parsedTime, err := time.Parse(layout, normalized)
+		if err == nil {
+			return parsedTime, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date format %q", dateStr)
+}
+
+func normalizeDate(dateStr string) string {
+	normalized := strings.Join(strings.Fields(dateStr), " ")
+	normalized = trailingComment.ReplaceAllString(normalized, "")
+	normalized = leadingWeekday.ReplaceAllString(normalized, "$1")
+	normalized = strings.Replace(normalized, " Sept ", " Sep ", 1)
+	if i := strings.LastIndexByte(normalized, ' '); i >= 0 {
+		if offset, ok := zoneOffsets[strings.ToUpper(normalized[i+1:])]; ok {
+			normalized = normalized[:i+1] + offset
+		}
+	}
+	return normalized
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDateFormats(t *testing.T) {
+	want := time.Date(2024, time.July, 26, 14, 30, 0, 0, time.UTC)
+	cases := []string{
+		"Fri, 26 Jul 2024 14:30:00 +0000",
+		"Fri, 26 Jul 2024 14:30:00 GMT",
+		"Fri, 26 Jul 2024 10:30:00 EDT",
+		"Fri, 26 Jul 2024 07:30:00 PDT",
+		"Fri, 26 Jul 2024 09:30:00 est",
+		"26 Jul 2024 14:30:00 +0000",
+		"Fri, 26 Jul 24 14:30:00 +0000",
+		"Fri, 26 Jul 2024 14:30 +0000",
+		"Fri, 26 Jul 2024 16:30:00 +02:00",
+		"Friday, 26 July 2024 14:30:00 GMT",
+		"Fri,  26 Jul 2024   14:30:00 +0000 ",
+		"Fri, 26 Jul 2024 14:30:00 GMT (Coordinated Universal Time)",
+		"Mon, 26 Jul 2024 14:30:00 +0000",
+		"2024-07-26T14:30:00Z",
+		"2024-07-26T14:30:00.000Z",
+		"2024-07-26T16:30:00+02:00",
+		"2024-07-26T16:30:00+0200",
+		"2024-07-26T14:30Z",
+		"2024-07-26T14:30:00",
+		"2024-07-26 14:30:00",
+		"2024-07-26 14:30:00 +0000",
+		"Fri Jul 26 14:30:00 2024",
+		"Fri Jul 26 14:30:00 UTC 2024",
+	}
+	for _, dateStr := range cases {
+		got, err := ParseDate(dateStr)
+		if err != nil {
+			t.Errorf("ParseDate(%q) returned an error: %v", dateStr, err)
+			continue
+		}
+		if !got.Equal(want) {
+			t.Errorf("ParseDate(%q) = %v, want %v", dateStr, got, want)
+		}
+	}
+
+	got, err := ParseDate("2024-07-26")
+	if err != nil || !got.Equal(time.Date(2024, time.July, 26, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("ParseDate(date only) = %v, %v", got, err)
+	}
+	got, err = ParseDate("Sun, 1 Sept 2024 08:00:00 GMT")
+	if err != nil || !got.Equal(time.Date(2024, time.September, 1, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("ParseDate(Sept) = %v, %v", got, err)
+	}
+}
+
+func TestParseDateErrors(t *testing.T) {
+	for _, dateStr := range []string{"", "   ", "yesterday", "32 Jul 2024 14:30:00 +0000", "2024-13-01"} {
+		got, err := ParseDate(dateStr)
+		if err == nil {
+			t.Errorf("ParseDate(%q) = %v, want an error", dateStr, got)
+		}
+	}
+}
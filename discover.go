@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"strings"
+
+	"github.com/rowinf/blog-aggregator/internal"
+)
+
+// errNoFeedFound is returned when a URL leads to an HTML page that does not
+// advertise any feed.
+var errNoFeedFound = errors.New("no feed found at this URL")
+
+// feedLinkTypes are the <link rel="alternate"> media types that point to a
+// feed ParseFeed understands.
+var feedLinkTypes = map[string]bool{
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/feed+json": true,
+	"application/rdf+xml":   true,
+}
+
+// FeedCandidate is a feed an HTML page advertises with a
+// <link rel="alternate"> element.
+type FeedCandidate struct {
+	URL   string
+	Title string
+	Type  string
+}
+
+// resolveFeedURL fetches the feed a user means by rawURL: rawURL itself
+// when it serves a feed, or the best feed advertised by the page when it
+// serves HTML. It returns the feed's URL along with its parsed contents.
+func resolveFeedURL(ctx context.Context, rawURL string) (string, RSS, error) {
+	result, body, err := fetchDocument(ctx, rawURL, CacheValidators{})
+	if err != nil {
+		return "", RSS{}, err
+	}
+	if !isHTML(result.ContentType, body) {
+		rss, err := ParseFeed(result.ContentType, body)
+		if err != nil {
+			return "", RSS{}, fmt.Errorf("failed to parse feed: %w", err)
+		}
+		// A feed that has moved permanently is stored at its new home.
+		if target := permanentRedirectTarget(result.Redirects); target != "" {
+			return target, rss, nil
+		}
+		return rawURL, rss, nil
+	}
+	pageURL, err := url.Parse(result.URL)
+	if err != nil {
+		return "", RSS{}, err
+	}
+	candidate, ok := bestFeedCandidate(discoverFeeds(body, pageURL))
+	if !ok {
+		return "", RSS{}, errNoFeedFound
+	}
+	rss, err := FetchRSSFeed(ctx, candidate.URL)
+	if err != nil {
+		return "", RSS{}, fmt.Errorf("advertised feed %s: %w", candidate.URL, err)
+	}
+	return candidate.URL, rss, nil
+}
+
+// isHTML reports whether a response is a web page rather than a feed,
+// trusting an HTML content type and otherwise sniffing for a doctype or
+// <html> root.
+func isHTML(contentType string, body []byte) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/html", "application/xhtml+xml":
+		return true
+	}
+	start := bytes.ToLower(bytes.TrimSpace(body))
+	if len(start) > 512 {
+		start = start[:512]
+	}
+	return bytes.HasPrefix(start, []byte("<!doctype html")) || bytes.HasPrefix(start, []byte("<html"))
+}
+
+// discoverFeeds lists the feeds a page advertises, in document order and
+// resolved against the page's <base> or URL.
+func discoverFeeds(page []byte, pageURL *url.URL) []FeedCandidate {
+	links, baseHref := internal.DocumentLinks(string(page))
+	base := pageURL
+	if baseHref != "" {
+		if baseURL, err := url.Parse(strings.TrimSpace(baseHref)); err == nil {
+			base = pageURL.ResolveReference(baseURL)
+		}
+	}
+	var candidates []FeedCandidate
+	seen := make(map[string]bool)
+	for _, link := range links {
+		if !hasLinkRel(link["rel"], "alternate") {
+			continue
+		}
+		mediaType, _, _ := mime.ParseMediaType(link["type"])
+		if !feedLinkTypes[mediaType] {
+			continue
+		}
+		href, err := url.Parse(strings.TrimSpace(link["href"]))
+		if err != nil || link["href"] == "" {
+			continue
+		}
+		feedURL := base.ResolveReference(href)
+		if feedURL.Scheme != "http" && feedURL.Scheme != "https" {
+			continue
+		}
+		if seen[feedURL.String()] {
+			continue
+		}
+		seen[feedURL.String()] = true
+		candidates = append(candidates, FeedCandidate{
+			URL:   feedURL.String(),
+			Title: strings.TrimSpace(link["title"]),
+			Type:  mediaType,
+		})
+	}
+	return candidates
+}
+
+// hasLinkRel reports whether a space separated rel attribute contains rel.
+func hasLinkRel(rels, rel string) bool {
+	for _, value := range strings.Fields(rels) {
+		if strings.EqualFold(value, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// bestFeedCandidate picks the page's main feed. Sites list it first by
+// convention, but blogs often also advertise a comments feed, which is
+// passed over unless it is the only one.
+func bestFeedCandidate(candidates []FeedCandidate) (FeedCandidate, bool) {
+	if len(candidates) == 0 {
+		return FeedCandidate{}, false
+	}
+	for _, candidate := range candidates {
+		if !isCommentsFeed(candidate) {
+			return candidate, true
+		}
+	}
+	return candidates[0], true
+}
+
+func isCommentsFeed(candidate FeedCandidate) bool {
+	if strings.Contains(strings.ToLower(candidate.Title), "comment") {
+		return true
+	}
+	feedURL, err := url.Parse(candidate.URL)
+	return err == nil && strings.Contains(strings.ToLower(feedURL.Path), "/comments")
+}
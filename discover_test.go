@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+const blogHomepage = `<!DOCTYPE html>
+<html>
+<head>
+  <title>Example Blog</title>
+  <link rel="stylesheet" href="/style.css">
+  <link rel="alternate" type="application/rss+xml" title="Example Blog &raquo; Comments Feed" href="/comments/feed/">
+  <link rel="alternate" type="application/rss+xml; charset=utf-8" title="Example Blog &raquo; Feed" href="/feed/">
+  <link rel="alternate" type="application/atom+xml" title="Atom" href="https://blog.example.com/feed/atom/">
+  <link rel="alternate" type="text/html" hreflang="fr" href="/fr/">
+  <link rel="alternate" type="application/rss+xml" href="/feed/">
+</head>
+<body><p>Welcome</p></body>
+</html>`
+
+func TestDiscoverFeeds(t *testing.T) {
+	pageURL, _ := url.Parse("https://blog.example.com/index.html")
+	candidates := discoverFeeds([]byte(blogHomepage), pageURL)
+	want := []FeedCandidate{
+		{URL: "https://blog.example.com/comments/feed/", Title: "Example Blog » Comments Feed", Type: "application/rss+xml"},
+		{URL: "https://blog.example.com/feed/", Title: "Example Blog » Feed", Type: "application/rss+xml"},
+		{URL: "https://blog.example.com/feed/atom/", Title: "Atom", Type: "application/atom+xml"},
+	}
+	if len(candidates) != len(want) {
+		t.Fatalf("got %d candidates, want %d: %+v", len(candidates), len(want), candidates)
+	}
+	for i := range want {
+		if candidates[i] != want[i] {
+			t.Errorf("candidate %d = %+v, want %+v", i, candidates[i], want[i])
+		}
+	}
+
+	best, ok := bestFeedCandidate(candidates)
+	if !ok || best.URL != "https://blog.example.com/feed/" {
+		t.Errorf("best candidate = %+v, want the main feed rather than comments", best)
+	}
+}
+
+func TestDiscoverFeedsBaseElement(t *testing.T) {
+	pageURL, _ := url.Parse("https://example.com/blog/")
+	page := `<html><head><base href="/static/"><link rel="feed alternate" type="application/feed+json" href="feed.json"></head></html>`
+	candidates := discoverFeeds([]byte(page), pageURL)
+	if len(candidates) != 1 || candidates[0].URL != "https://example.com/static/feed.json" {
+		t.Errorf("candidates = %+v", candidates)
+	}
+}
+
+func TestBestFeedCandidate(t *testing.T) {
+	if _, ok := bestFeedCandidate(nil); ok {
+		t.Error("bestFeedCandidate(nil) should find nothing")
+	}
+	onlyComments := []FeedCandidate{{URL: "https://example.com/comments/feed/", Title: "Comments"}}
+	if best, ok := bestFeedCandidate(onlyComments); !ok || best != onlyComments[0] {
+		t.Errorf("bestFeedCandidate = %+v, want the comments feed when it is the only one", best)
+	}
+}
+
+func TestResolveFeedURL(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(blogHomepage))
+	})
+	mux.HandleFunc("/feed/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(rssFixture))
+	})
+	mux.HandleFunc("/empty", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>No feeds</title></head></html>`))
+	})
+	mux.HandleFunc("/plain", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("just text"))
+	})
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"ok"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	got, rss, err := resolveFeedURL(context.Background(), server.URL+"/")
+	if err != nil || got != server.URL+"/feed/" {
+		t.Errorf("resolveFeedURL(homepage) = %q, %v, want the advertised feed", got, err)
+	}
+	if rss.Channel.Title != "Boot.dev Blog" {
+		t.Errorf("resolveFeedURL(homepage) title = %q, want the advertised feed's", rss.Channel.Title)
+	}
+	got, rss, err = resolveFeedURL(context.Background(), server.URL+"/feed/")
+	if err != nil || got != server.URL+"/feed/" || rss.Channel.Title != "Boot.dev Blog" {
+		t.Errorf("resolveFeedURL(feed) = %q, %q, %v, want the feed itself", got, rss.Channel.Title, err)
+	}
+	if _, _, err := resolveFeedURL(context.Background(), server.URL+"/empty"); !errors.Is(err, errNoFeedFound) {
+		t.Errorf("resolveFeedURL(page without feeds) error = %v, want errNoFeedFound", err)
+	}
+	if _, _, err := resolveFeedURL(context.Background(), server.URL+"/plain"); err == nil {
+		t.Error("resolveFeedURL should reject a document that is neither a feed nor a page")
+	}
+	if _, _, err := resolveFeedURL(context.Background(), server.URL+"/api"); !errors.Is(err, errNotJSONFeed) {
+		t.Errorf("resolveFeedURL(JSON API) error = %v, want errNotJSONFeed", err)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rowinf/blog-aggregator/internal/database"
+)
+
+type Enclosure struct {
+	URL    string `xml:"url,attr"`
+	Length string `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type ITunesImage struct {
+	Href string `xml:"href,attr"`
+}
+
+type EnclosureParams struct {
+	Url             string `json:"url"`
+	Length          *int64 `json:"length"`
+	MimeType        string `json:"mime_type"`
+	DurationSeconds *int32 `json:"duration_seconds"`
+	Episode         *int32 `json:"episode"`
+	Season          *int32 `json:"season"`
+	ImageUrl        string `json:"image_url"`
+}
+
+func (params *EnclosureParams) asJSON(enclosure database.PostEnclosure) *EnclosureParams {
+	params.Url = enclosure.Url
+	params.MimeType = enclosure.MimeType.String
+	params.ImageUrl = enclosure.ImageUrl.String
+	if enclosure.Length.Valid {
+		params.Length = &enclosure.Length.Int64
+	}
+	if enclosure.DurationSeconds.Valid {
+		params.DurationSeconds = &enclosure.DurationSeconds.Int32
+	}
+	if enclosure.Episode.Valid {
+		params.Episode = &enclosure.Episode.Int32
+	}
+	if enclosure.Season.Valid {
+		params.Season = &enclosure.Season.Int32
+	}
+	return params
+}
+
+// enclosureParams builds the rows stored for an item's enclosures. Podcast
+// metadata applies to the whole episode, so it is repeated on each one, and
+// the channel artwork stands in when the episode has none of its own.
+func enclosureParams(channel Channel, item Item, feedID, guid string) []database.UpsertPostEnclosureParams {
+	image := strings.TrimSpace(item.ITunesImage.Href)
+	if image == "" {
+		image = strings.TrimSpace(channel.ITunesImage.Href)
+	}
+	duration, durationOK := parseITunesDuration(item.ITunesDuration)
+	episode, episodeErr := strconv.Atoi(strings.TrimSpace(item.ITunesEpisode))
+	season, seasonErr := strconv.Atoi(strings.TrimSpace(item.ITunesSeason))
+
+	params := make([]database.UpsertPostEnclosureParams, 0, len(item.Enclosures))
+	for _, enclosure := range item.Enclosures {
+		url := strings.TrimSpace(enclosure.URL)
+		if url == "" {
+			continue
+		}
+		length, lengthErr := strconv.ParseInt(strings.TrimSpace(enclosure.Length), 10, 64)
+		params = append(params, database.UpsertPostEnclosureParams{
+			ID:              uuid.NewString(),
+			CreatedAt:       time.Now(),
+			FeedID:          feedID,
+			Guid:            guid,
+			Url:             url,
+			Length:          sql.NullInt64{Int64: length, Valid: lengthErr == nil && length > 0},
+			MimeType:        sql.NullString{String: strings.TrimSpace(enclosure.Type), Valid: strings.TrimSpace(enclosure.Type) != ""},
+			DurationSeconds: sql.NullInt32{Int32: int32(duration), Valid: durationOK},
+			Episode:         sql.NullInt32{Int32: int32(episode), Valid: episodeErr == nil},
+			Season:          sql.NullInt32{Int32: int32(season), Valid: seasonErr == nil},
+			ImageUrl:        sql.NullString{String: image, Valid: image != ""},
+		})
+	}
+	return params
+}
+
+// parseITunesDuration reads itunes:duration, which is either a number of
+// seconds or HH:MM:SS / MM:SS.
+func parseITunesDuration(duration string) (int, bool) {
+	duration = strings.TrimSpace(duration)
+	if duration == "" {
+		return 0, false
+	}
+	parts := strings.Split(duration, ":")
+	if len(parts) > 3 {
+		return 0, false
+	}
+	seconds := 0
+	for _, part := range parts {
+		value, err := strconv.ParseFloat(part, 64)
+		if err != nil || value < 0 {
+			return 0, false
+		}
+		seconds = seconds*60 + int(value)
+	}
+	return seconds, true
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"testing"
+)
+
+const podcastFixture = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd">
+  <channel>
+    <title>Go Time</title>
+    <itunes:image href="https://cdn.example.com/show.png"/>
+    <item>
+      <title>Episode 42</title>
+      <guid>gotime-42</guid>
+      <enclosure url="https://cdn.example.com/42.mp3" length="48123456" type="audio/mpeg"/>
+      <itunes:duration>1:02:03</itunes:duration>
+      <itunes:episode>42</itunes:episode>
+      <itunes:season>3</itunes:season>
+      <itunes:image href="https://cdn.example.com/42.png"/>
+    </item>
+    <item>
+      <title>Trailer</title>
+      <guid>gotime-trailer</guid>
+      <enclosure url="https://cdn.example.com/trailer.mp3" type="audio/mpeg"/>
+      <itunes:duration>95</itunes:duration>
+    </item>
+  </channel>
+</rss>`
+
+func TestEnclosureParams(t *testing.T) {
+	rss, err := ParseFeed("application/rss+xml", []byte(podcastFixture))
+	if err != nil {
+		t.Fatalf("ParseFeed returned an error: %v", err)
+	}
+	channel := rss.Channel
+
+	episode := enclosureParams(channel, channel.Items[0], "feed-1", "gotime-42")
+	if len(episode) != 1 {
+		t.Fatalf("got %d enclosures, want 1", len(episode))
+	}
+	got := episode[0]
+	if got.Url != "https://cdn.example.com/42.mp3" || got.MimeType.String != "audio/mpeg" || got.Length.Int64 != 48123456 {
+		t.Errorf("enclosure = %+v", got)
+	}
+	if got.DurationSeconds.Int32 != 3723 || got.Episode.Int32 != 42 || got.Season.Int32 != 3 {
+		t.Errorf("duration, episode, season = %v, %v, %v", got.DurationSeconds, got.Episode, got.Season)
+	}
+	if got.ImageUrl.String != "https://cdn.example.com/42.png" {
+		t.Errorf("image = %q, want episode artwork", got.ImageUrl.String)
+	}
+	if got.FeedID != "feed-1" || got.Guid != "gotime-42" || got.ID == "" {
+		t.Errorf("keys = %q, %q, %q", got.ID, got.FeedID, got.Guid)
+	}
+
+	trailer := enclosureParams(channel, channel.Items[1], "feed-1", "gotime-trailer")[0]
+	if trailer.Length.Valid || trailer.Episode.Valid || trailer.Season.Valid {
+		t.Errorf("missing fields should be null: %+v", trailer)
+	}
+	if trailer.DurationSeconds.Int32 != 95 {
+		t.Errorf("duration = %v, want 95", trailer.DurationSeconds)
+	}
+	if trailer.ImageUrl.String != "https://cdn.example.com/show.png" {
+		t.Errorf("image = %q, want channel artwork fallback", trailer.ImageUrl.String)
+	}
+}
+
+func TestParseFeedPrefersRSSTitleToITunesTitle(t *testing.T) {
+	body := `<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd"><channel>
+  <item>
+    <title>Episode 42: Generics</title>
+    <itunes:title>Generics</itunes:title>
+    <description>Show notes</description>
+    <itunes:summary>Short summary</itunes:summary>
+    <guid>gotime-42</guid>
+  </item>
+  <item>
+    <itunes:title>Bonus</itunes:title>
+    <guid>gotime-bonus</guid>
+  </item>
+</channel></rss>`
+	rss, err := ParseFeed("application/rss+xml", []byte(body))
+	if err != nil {
+		t.Fatalf("ParseFeed returned an error: %v", err)
+	}
+	episode := rss.Channel.Items[0]
+	if episode.Title != "Episode 42: Generics" || episode.ITunesTitle != "Generics" {
+		t.Errorf("title, itunes title = %q, %q", episode.Title, episode.ITunesTitle)
+	}
+	if episode.Description != "Show notes" || episode.ITunesSummary != "Short summary" {
+		t.Errorf("description, itunes summary = %q, %q", episode.Description, episode.ITunesSummary)
+	}
+	if bonus := rss.Channel.Items[1]; bonus.Title != "Bonus" {
+		t.Errorf("title = %q, want the itunes title when there is no other", bonus.Title)
+	}
+}
+
+func TestParseITunesDuration(t *testing.T) {
+	cases := []struct {
+		duration string
+		want     int
+		ok       bool
+	}{
+		{"3723", 3723, true},
+		{"62:03", 3723, true},
+		{"1:02:03", 3723, true},
+		{" 00:45 ", 45, true},
+		{"", 0, false},
+		{"long", 0, false},
+		{"1:2:3:4", 0, false},
+	}
+	for _, c := range cases {
+		got, ok := parseITunesDuration(c.duration)
+		if got != c.want || ok != c.ok {
+			t.Errorf("parseITunesDuration(%q) = %d, %v, want %d, %v", c.duration, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestParseFeedEnclosures(t *testing.T) {
+	atom := `<feed xmlns="http://www.w3.org/2005/Atom"><entry><id>e1</id>
+  <link rel="enclosure" href="https://cdn.example.com/e1.mp3" type="audio/mpeg" length="1000"/>
+</entry></feed>`
+	rss, err := ParseFeed("", []byte(atom))
+	if err != nil {
+		t.Fatalf("ParseFeed returned an error: %v", err)
+	}
+	if got := rss.Channel.Items[0].Enclosures; len(got) != 1 || got[0] != (Enclosure{URL: "https://cdn.example.com/e1.mp3", Length: "1000", Type: "audio/mpeg"}) {
+		t.Errorf("atom enclosures = %+v", got)
+	}
+
+	jsonFeed := `{"version": "https://jsonfeed.org/version/1.1", "items": [{"id": "j1", "image": "https://cdn.example.com/j1.png",
+  "attachments": [{"url": "https://cdn.example.com/j1.m4a", "mime_type": "audio/x-m4a", "size_in_bytes": 2000, "duration_in_seconds": 61}]}]}`
+	rss, err = ParseFeed("application/feed+json", []byte(jsonFeed))
+	if err != nil {
+		t.Fatalf("ParseFeed returned an error: %v", err)
+	}
+	item := rss.Channel.Items[0]
+	if len(item.Enclosures) != 1 || item.Enclosures[0] != (Enclosure{URL: "https://cdn.example.com/j1.m4a", Length: "2000", Type: "audio/x-m4a"}) {
+		t.Errorf("json feed enclosures = %+v", item.Enclosures)
+	}
+	if item.ITunesDuration != "61" || item.ITunesImage.Href != "https://cdn.example.com/j1.png" {
+		t.Errorf("json feed duration, image = %q, %q", item.ITunesDuration, item.ITunesImage.Href)
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rowinf/blog-aggregator/internal/database"
+)
+
+// fakeDB is a database/sql driver for tests that exercise code built on
+// database.Queries without Postgres. It records the sqlc queries it runs
+// by name and answers queries with rows set up by the test; anything it
+// has no rows for comes back empty.
+type fakeDB struct {
+	mu        sync.Mutex
+	calls     []fakeCall
+	rows      map[string][][]driver.Value
+	answerFns map[string]func(args []driver.Value) []any
+	errors    map[string]error
+}
+
+// fakeCall is one statement run against a fakeDB. Transactions show up
+// as BEGIN, COMMIT and ROLLBACK calls.
+type fakeCall struct {
+	Name string
+	Args []driver.Value
+}
+
+var (
+	fakeDBsMu sync.Mutex
+	fakeDBs   = make(map[string]*fakeDB)
+	fakeDBSeq int
+)
+
+func init() {
+	sql.Register("fakedb", fakeDriver{})
+}
+
+// newFakeDB returns an ApiConfig backed by a fresh fakeDB.
+func newFakeDB(t *testing.T) (*ApiConfig, *fakeDB) {
+	fake := &fakeDB{
+		rows:      make(map[string][][]driver.Value),
+		answerFns: make(map[string]func([]driver.Value) []any),
+		errors:    make(map[string]error),
+	}
+	fakeDBsMu.Lock()
+	fakeDBSeq++
+	name := fmt.Sprintf("%s#%d", t.Name(), fakeDBSeq)
+	fakeDBs[name] = fake
+	fakeDBsMu.Unlock()
+	db, err := sql.Open("fakedb", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		fakeDBsMu.Lock()
+		delete(fakeDBs, name)
+		fakeDBsMu.Unlock()
+	})
+	return &ApiConfig{DB: database.New(db), DBConn: db}, fake
+}
+
+// returns makes the named query answer with rows, each a struct whose
+// fields are in column order, such as a database.Feed.
+func (f *fakeDB) returns(name string, rows ...any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, row := range rows {
+		f.rows[name] = append(f.rows[name], structValues(row))
+	}
+}
+
+// answers makes the named query answer with the rows fn builds from the
+// arguments of each run, for rows that depend on values generated by the
+// code under test.
+func (f *fakeDB) answers(name string, fn func(args []driver.Value) []any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.answerFns[name] = fn
+}
+
+// fails makes every run of the named statement fail with err.
+func (f *fakeDB) fails(name string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errors[name] = err
+}
+
+// called lists the names of the statements run so far, in order.
+func (f *fakeDB) called() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	names := make([]string, len(f.calls))
+	for i, call := range f.calls {
+		names[i] = call.Name
+	}
+	return names
+}
+
+// call returns the first run of the named statement.
+func (f *fakeDB) call(name string) (fakeCall, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, call := range f.calls {
+		if call.Name == name {
+			return call, true
+		}
+	}
+	return fakeCall{}, false
+}
+
+func (f *fakeDB) record(query string, args []driver.NamedValue) ([][]driver.Value, error) {
+	name := query
+	if rest, ok := strings.CutPrefix(query, "-- name: "); ok {
+		name, _, _ = strings.Cut(rest, " ")
+	}
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, fakeCall{Name: name, Args: values})
+	if answer, ok := f.answerFns[name]; ok {
+		var rows [][]driver.Value
+		for _, row := range answer(values) {
+			rows = append(rows, structValues(row))
+		}
+		return rows, f.errors[name]
+	}
+	return f.rows[name], f.errors[name]
+}
+
+// structValues flattens a row struct into driver values in field order.
+func structValues(row any) []driver.Value {
+	v := reflect.ValueOf(row)
+	values := make([]driver.Value, v.NumField())
+	for i := range values {
+		field := v.Field(i).Interface()
+		if valuer, ok := field.(driver.Valuer); ok {
+			value, err := valuer.Value()
+			if err != nil {
+				panic(err)
+			}
+			values[i] = value
+			continue
+		}
+		value, err := driver.DefaultParameterConverter.ConvertValue(field)
+		if err != nil {
+			panic(fmt.Sprintf("field %s: %v", v.Type().Field(i).Name, err))
+		}
+		values[i] = value
+	}
+	return values
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeDBsMu.Lock()
+	defer fakeDBsMu.Unlock()
+	fake, ok := fakeDBs[name]
+	if !ok {
+		return nil, fmt.Errorf("no fake database %q", name)
+	}
+	return &fakeConn{db: fake}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fakedb does not prepare statements")
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.db.record("BEGIN", nil)
+	return fakeTx{db: c.db}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if _, err := c.db.record(query, args); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := c.db.record(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{rows: rows}, nil
+}
+
+type fakeTx struct {
+	db *fakeDB
+}
+
+func (tx fakeTx) Commit() error {
+	tx.db.record("COMMIT", nil)
+	return nil
+}
+
+func (tx fakeTx) Rollback() error {
+	tx.db.record("ROLLBACK", nil)
+	return nil
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	columns := make([]string, len(r.rows[0]))
+	for i := range columns {
+		columns[i] = fmt.Sprintf("column%d", i)
+	}
+	return columns
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+)
+
+// ParseFeed detects the feed format from the response content type and the
+// document's root element and decodes it into the RSS model. Legacy
+// charsets named by the content type or the XML declaration are transcoded
+// to UTF-8 first.
+func ParseFeed(contentType string, body []byte) (RSS, error) {
+	_, params, _ := mime.ParseMediaType(contentType)
+	httpCharset := params["charset"]
+	if !knownCharset(httpCharset) {
+		// Leave unknown or missing charsets to the XML declaration.
+		httpCharset = ""
+	}
+	if isJSONFeed(contentType, body) {
+		if httpCharset != "" {
+			decoded, err := decodeCharset(httpCharset, body)
+			if err != nil {
+				return RSS{}, err
+			}
+			body = decoded
+		}
+		var feed JSONFeed
+		if err := json.Unmarshal(body, &feed); err != nil {
+			return RSS{}, err
+		}
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		if !feed.valid(mediaType) {
+			return RSS{}, errNotJSONFeed
+		}
+		return feed.toRSS(), nil
+	}
+	root, err := rootElement(body, httpCharset)
+	if err != nil {
+		return RSS{}, err
+	}
+	switch root.Local {
+	case "rss":
+		var rss RSS
+		if err := decodeFeed(body, httpCharset, &rss); err != nil {
+			return RSS{}, err
+		}
+		for i := range rss.Channel.Items {
+			item := &rss.Channel.Items[i]
+			if item.Title == "" {
+				item.Title = item.ITunesTitle
+			}
+		}
+		return rss, nil
+	case "feed":
+		var atom AtomFeed
+		if err := decodeFeed(body, httpCharset, &atom); err != nil {
+			return RSS{}, err
+		}
+		return atom.toRSS(), nil
+	case "RDF":
+		if root.Space != rdfNamespace {
+			return RSS{}, fmt.Errorf("unsupported RDF namespace %q", root.Space)
+		}
+		var rdf RDF
+		if err := decodeFeed(body, httpCharset, &rdf); err != nil {
+			return RSS{}, err
+		}
+		return rdf.toRSS(), nil
+	default:
+		return RSS{}, fmt.Errorf("unsupported feed format: <%s>", root.Local)
+	}
+}
+
+func decodeFeed(body []byte, httpCharset string, v any) error {
+	decoder, err := newFeedDecoder(body, httpCharset)
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(v)
+}
+
+// isJSONFeed trusts an application/feed+json or application/json content
+// type, and otherwise sniffs for a JSON object since many servers send JSON
+// Feeds as text/plain.
+func isJSONFeed(contentType string, body []byte) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "application/feed+json", "application/json":
+		return true
+	}
+	return bytes.HasPrefix(bytes.TrimSpace(body), []byte("{"))
+}
+
+func rootElement(body []byte, httpCharset string) (xml.Name, error) {
+	decoder, err := newFeedDecoder(body, httpCharset)
+	if err != nil {
+		return xml.Name{}, err
+	}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return xml.Name{}, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name, nil
+		}
+	}
+}
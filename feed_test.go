@@ -0,0 +1,358 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+const atomFixture = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Spring News</title>
+  <subtitle>News and events</subtitle>
+  <link href="https://spring.io/blog/category/news.atom" rel="self"/>
+  <link href="https://spring.io/blog"/>
+  <updated>2024-07-26T10:00:00Z</updated>
+  <id>https://spring.io/blog/category/news</id>
+  <entry>
+    <title>Spring Boot 3.3 released</title>
+    <link rel="alternate" href="https://spring.io/blog/2024/07/26/boot-3-3"/>
+    <id>tag:spring.io,2024-07-26:4242</id>
+    <updated>2024-07-26T12:00:00Z</updated>
+    <published>2024-07-26T09:30:00Z</published>
+    <content type="html">&lt;p&gt;Full release notes&lt;/p&gt;</content>
+  </entry>
+  <entry>
+    <title>Spring Framework 6.1</title>
+    <link href="https://spring.io/blog/2024/07/20/framework-6-1"/>
+    <id>tag:spring.io,2024-07-20:4241</id>
+    <updated>2024-07-20T08:00:00Z</updated>
+    <summary>Short summary</summary>
+  </entry>
+</feed>`
+
+const rssFixture = `<?xml version="1.0" encoding="utf-8"?>
+<rss version="2.0">
+  <channel>
+    <title>Boot.dev Blog</title>
+    <link>https://blog.boot.dev/</link>
+    <item>
+      <title>Learn Go</title>
+      <link>https://blog.boot.dev/golang/learn-go/</link>
+      <pubDate>Fri, 26 Jul 2024 00:00:00 +0000</pubDate>
+      <guid>https://blog.boot.dev/golang/learn-go/</guid>
+      <description>Go is great</description>
+    </item>
+  </channel>
+</rss>`
+
+func TestParseFeedRSS(t *testing.T) {
+	rss, err := ParseFeed("", []byte(rssFixture))
+	if err != nil {
+		t.Fatalf("ParseFeed returned an error: %v", err)
+	}
+	if rss.Channel.Title != "Boot.dev Blog" {
+		t.Errorf("title = %q, want %q", rss.Channel.Title, "Boot.dev Blog")
+	}
+	if len(rss.Channel.Items) != 1 || rss.Channel.Items[0].Link != "https://blog.boot.dev/golang/learn-go/" {
+		t.Errorf("unexpected items %+v", rss.Channel.Items)
+	}
+}
+
+func TestParseFeedRSSItemAtomLinks(t *testing.T) {
+	body := `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom"><channel>
+  <item>
+    <link>https://news.example.com/story</link>
+    <atom:link href="https://news.example.com/story.amp" rel="amphtml"/>
+    <title>Story</title>
+  </item>
+</channel></rss>`
+	rss, err := ParseFeed("", []byte(body))
+	if err != nil {
+		t.Fatalf("ParseFeed returned an error: %v", err)
+	}
+	item := rss.Channel.Items[0]
+	if item.Link != "https://news.example.com/story" {
+		t.Errorf("link = %q, want the RSS link", item.Link)
+	}
+	want := []AtomLink{{Href: "https://news.example.com/story.amp", Rel: "amphtml"}}
+	if !reflect.DeepEqual(item.AtomLinks, want) {
+		t.Errorf("atom links = %+v, want %+v", item.AtomLinks, want)
+	}
+	if guid := postGUID(item); guid != "https://news.example.com/story" {
+		t.Errorf("postGUID = %q, want the RSS link", guid)
+	}
+}
+
+func TestParseFeedAtom(t *testing.T) {
+	rss, err := ParseFeed("", []byte(atomFixture))
+	if err != nil {
+		t.Fatalf("ParseFeed returned an error: %v", err)
+	}
+	if rss.Channel.Title != "Spring News" || rss.Channel.Link != "https://spring.io/blog" {
+		t.Errorf("channel = %q %q", rss.Channel.Title, rss.Channel.Link)
+	}
+	if len(rss.Channel.Items) != 2 {
+		t.Fatalf("got %d items, want 2", len(rss.Channel.Items))
+	}
+
+	first := rss.Channel.Items[0]
+	if first.Link != "https://spring.io/blog/2024/07/26/boot-3-3" {
+		t.Errorf("link = %q", first.Link)
+	}
+	if first.GUID != "tag:spring.io,2024-07-26:4242" {
+		t.Errorf("guid = %q", first.GUID)
+	}
+	if first.PubDate != "2024-07-26T09:30:00Z" {
+		t.Errorf("pubDate = %q, want published date", first.PubDate)
+	}
+	if first.Description != "<p>Full release notes</p>" {
+		t.Errorf("description = %q, want content fallback", first.Description)
+	}
+
+	second := rss.Channel.Items[1]
+	if second.PubDate != "2024-07-20T08:00:00Z" {
+		t.Errorf("pubDate = %q, want updated date fallback", second.PubDate)
+	}
+	if second.Description != "Short summary" {
+		t.Errorf("description = %q", second.Description)
+	}
+	if _, err := ParseDate(second.PubDate); err != nil {
+		t.Errorf("ParseDate(%q) returned an error: %v", second.PubDate, err)
+	}
+}
+
+func TestParseFeedUnsupported(t *testing.T) {
+	if _, err := ParseFeed("", []byte(`<html><body>hi</body></html>`)); err == nil {
+		t.Error("ParseFeed should reject documents that are not feeds")
+	}
+}
+
+const jsonFeedFixture = `{
+  "version": "https://jsonfeed.org/version/1.1",
+  "title": "Daring Notes",
+  "home_page_url": "https://notes.example.com/",
+  "items": [
+    {
+      "id": "2347259",
+      "url": "https://notes.example.com/2024/07/26/json-feed",
+      "title": "JSON Feed is neat",
+      "content_html": "<p>Hello</p>",
+      "content_text": "Hello",
+      "date_published": "2024-07-26T14:30:00-07:00",
+      "authors": [{"name": "Ada"}, {"name": "Grace"}]
+    },
+    {
+      "id": "https://notes.example.com/2024/07/25/linked",
+      "external_url": "https://elsewhere.example.org/story",
+      "content_text": "A linked item",
+      "date_modified": "2024-07-25T08:00:00Z",
+      "author": {"name": "Ada"}
+    }
+  ]
+}`
+
+func TestParseFeedJSONFeed(t *testing.T) {
+	for _, contentType := range []string{"application/feed+json; charset=utf-8", "text/plain"} {
+		rss, err := ParseFeed(contentType, []byte(jsonFeedFixture))
+		if err != nil {
+			t.Fatalf("ParseFeed(%q) returned an error: %v", contentType, err)
+		}
+		if rss.Channel.Title != "Daring Notes" || len(rss.Channel.Items) != 2 {
+			t.Fatalf("ParseFeed(%q) = %+v", contentType, rss.Channel)
+		}
+
+		first := rss.Channel.Items[0]
+		if first.GUID != "2347259" || first.Link != "https://notes.example.com/2024/07/26/json-feed" {
+			t.Errorf("guid, link = %q, %q", first.GUID, first.Link)
+		}
+		if first.Description != "<p>Hello</p>" {
+			t.Errorf("description = %q, want content_html", first.Description)
+		}
+		if first.Author != "Ada, Grace" {
+			t.Errorf("author = %q", first.Author)
+		}
+
+		second := rss.Channel.Items[1]
+		if second.Link != "https://elsewhere.example.org/story" {
+			t.Errorf("link = %q, want external_url fallback", second.Link)
+		}
+		if second.Description != "A linked item" || second.Author != "Ada" {
+			t.Errorf("description, author = %q, %q", second.Description, second.Author)
+		}
+		if second.PubDate != "2024-07-25T08:00:00Z" {
+			t.Errorf("pubDate = %q, want date_modified fallback", second.PubDate)
+		}
+	}
+}
+
+func TestParseFeedJSONFeedNumericIDs(t *testing.T) {
+	body := `{"version": "https://jsonfeed.org/version/1.1", "items": [
+  {"id": 2347259, "url": "https://notes.example.com/1"},
+  {"id": 1.5e3, "url": "https://notes.example.com/2"},
+  {"id": "abc", "url": "https://notes.example.com/3"},
+  {"id": null, "url": "https://notes.example.com/4"}
+]}`
+	rss, err := ParseFeed("application/feed+json", []byte(body))
+	if err != nil {
+		t.Fatalf("ParseFeed returned an error: %v", err)
+	}
+	var guids []string
+	for _, item := range rss.Channel.Items {
+		guids = append(guids, item.GUID)
+	}
+	if want := []string{"2347259", "1.5e3", "abc", ""}; !reflect.DeepEqual(guids, want) {
+		t.Errorf("guids = %q, want %q", guids, want)
+	}
+
+	if _, err := ParseFeed("application/feed+json", []byte(`{"items": [{"id": {"x": 1}}]}`)); err == nil {
+		t.Error("ParseFeed accepted an object id")
+	}
+}
+
+const rdfFixture = `<?xml version="1.0" encoding="utf-8"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+  xmlns="http://purl.org/rss/1.0/"
+  xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <channel rdf:about="https://news.example.org/">
+    <title>Example News</title>
+    <link>https://news.example.org/</link>
+    <description>News for nerds</description>
+    <dc:language>en-us</dc:language>
+    <items>
+      <rdf:Seq>
+        <rdf:li rdf:resource="https://news.example.org/story/1"/>
+      </rdf:Seq>
+    </items>
+  </channel>
+  <item rdf:about="https://news.example.org/story/1">
+    <title>First story</title>
+    <link>https://news.example.org/story/1</link>
+    <description>Something happened</description>
+    <dc:creator>timothy</dc:creator>
+    <dc:date>2024-07-26T10:15:00+00:00</dc:date>
+  </item>
+</rdf:RDF>`
+
+func TestParseFeedRDF(t *testing.T) {
+	rss, err := ParseFeed("application/rdf+xml", []byte(rdfFixture))
+	if err != nil {
+		t.Fatalf("ParseFeed returned an error: %v", err)
+	}
+	if rss.Channel.Title != "Example News" || rss.Channel.Language != "en-us" {
+		t.Errorf("channel = %+v", rss.Channel)
+	}
+	if len(rss.Channel.Items) != 1 {
+		t.Fatalf("got %d items, want 1", len(rss.Channel.Items))
+	}
+	item := rss.Channel.Items[0]
+	if item.GUID != "https://news.example.org/story/1" || item.Link != "https://news.example.org/story/1" {
+		t.Errorf("guid, link = %q, %q", item.GUID, item.Link)
+	}
+	if item.Author != "timothy" {
+		t.Errorf("author = %q, want dc:creator", item.Author)
+	}
+	if item.PubDate != "2024-07-26T10:15:00+00:00" {
+		t.Errorf("pubDate = %q, want dc:date", item.PubDate)
+	}
+	if _, err := ParseDate(item.PubDate); err != nil {
+		t.Errorf("ParseDate(%q) returned an error: %v", item.PubDate, err)
+	}
+}
+
+func TestParseFeedFullContent(t *testing.T) {
+	rssBody := `<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/"><channel>
+  <item>
+    <title>Teaser</title>
+    <description>Read more...</description>
+    <content:encoded><![CDATA[<p>The whole article.</p>]]></content:encoded>
+  </item>
+</channel></rss>`
+	rss, err := ParseFeed("", []byte(rssBody))
+	if err != nil {
+		t.Fatalf("ParseFeed returned an error: %v", err)
+	}
+	if item := rss.Channel.Items[0]; item.Description != "Read more..." || item.Content != "<p>The whole article.</p>" {
+		t.Errorf("rss description, content = %q, %q", item.Description, item.Content)
+	}
+
+	rss, err = ParseFeed("", []byte(atomFixture))
+	if err != nil {
+		t.Fatalf("ParseFeed returned an error: %v", err)
+	}
+	if item := rss.Channel.Items[0]; item.Content != "<p>Full release notes</p>" {
+		t.Errorf("atom content = %q", item.Content)
+	}
+	if item := rss.Channel.Items[1]; item.Description != "Short summary" || item.Content != "" {
+		t.Errorf("atom description, content = %q, %q", item.Description, item.Content)
+	}
+
+	jsonBody := `{"version": "https://jsonfeed.org/version/1.1", "items": [
+  {"id": "1", "summary": "Short", "content_html": "<p>Long</p>"}]}`
+	rss, err = ParseFeed("application/feed+json", []byte(jsonBody))
+	if err != nil {
+		t.Fatalf("ParseFeed returned an error: %v", err)
+	}
+	if item := rss.Channel.Items[0]; item.Description != "Short" || item.Content != "<p>Long</p>" {
+		t.Errorf("json feed description, content = %q, %q", item.Description, item.Content)
+	}
+}
+
+func TestParseFeedAuthorsAndCategories(t *testing.T) {
+	rssBody := `<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/"><channel>
+  <item>
+    <title>Tagged</title>
+    <dc:creator>Rob Pike</dc:creator>
+    <dc:creator>Ken Thompson</dc:creator>
+    <category>Go</category>
+    <category domain="https://example.com/tags">Concurrency</category>
+  </item>
+</channel></rss>`
+	rss, err := ParseFeed("", []byte(rssBody))
+	if err != nil {
+		t.Fatalf("ParseFeed returned an error: %v", err)
+	}
+	item := rss.Channel.Items[0]
+	if postAuthor(item) != "Rob Pike, Ken Thompson" || !reflect.DeepEqual(item.Categories, []string{"Go", "Concurrency"}) {
+		t.Errorf("rss author, categories = %q, %q", postAuthor(item), item.Categories)
+	}
+	if got := postAuthors(item); !reflect.DeepEqual(got, []string{"rob pike", "ken thompson"}) {
+		t.Errorf("rss authors = %q, want every dc:creator", got)
+	}
+
+	atomBody := `<feed xmlns="http://www.w3.org/2005/Atom"><entry>
+  <id>1</id>
+  <author><name>Ada</name></author>
+  <author><name>Grace</name></author>
+  <category term="go" label="Go"/>
+  <category label="Release Notes"/>
+</entry></feed>`
+	rss, err = ParseFeed("", []byte(atomBody))
+	if err != nil {
+		t.Fatalf("ParseFeed returned an error: %v", err)
+	}
+	item = rss.Channel.Items[0]
+	if item.Author != "Ada, Grace" || !reflect.DeepEqual(item.Categories, []string{"go", "Release Notes"}) {
+		t.Errorf("atom author, categories = %q, %q", item.Author, item.Categories)
+	}
+
+	jsonBody := `{"version": "https://jsonfeed.org/version/1.1", "items": [{"id": "1", "tags": ["go", "json"]}]}`
+	rss, err = ParseFeed("application/feed+json", []byte(jsonBody))
+	if err != nil {
+		t.Fatalf("ParseFeed returned an error: %v", err)
+	}
+	if item := rss.Channel.Items[0]; !reflect.DeepEqual(item.Categories, []string{"go", "json"}) {
+		t.Errorf("json feed categories = %q", item.Categories)
+	}
+
+	rdfBody := `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/"
+  xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <item rdf:about="https://news.example.org/story/1"><dc:subject>Linux</dc:subject></item>
+</rdf:RDF>`
+	rss, err = ParseFeed("", []byte(rdfBody))
+	if err != nil {
+		t.Fatalf("ParseFeed returned an error: %v", err)
+	}
+	if item := rss.Channel.Items[0]; !reflect.DeepEqual(item.Categories, []string{"Linux"}) {
+		t.Errorf("rdf categories = %q", item.Categories)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/rowinf/blog-aggregator/internal"
+	"github.com/rowinf/blog-aggregator/internal/database"
+)
+
+// Feed statuses. Fetching moves a feed between active and erroring and
+// finally to dead; only its owner can pause it or bring it back. A feed
+// that permanently redirects to another feed's URL is merged into it for
+// good.
+const (
+	feedStatusActive   = "active"
+	feedStatusErroring = "erroring"
+	feedStatusDead     = "dead"
+	feedStatusPaused   = "paused"
+	feedStatusMerged   = "merged"
+)
+
+const (
+	// A feed is declared dead once it has failed at least deadFeedFailures
+	// times in a row over at least deadFeedAfter, so a server that is down
+	// for a weekend is retried but one that has been 404ing for weeks is not.
+	deadFeedFailures = 10
+	deadFeedAfter    = 14 * 24 * time.Hour
+)
+
+// FeedStatusParams is the body of PUT /v1/feeds/{feedID}/status.
+type FeedStatusParams struct {
+	Status string `json:"status"`
+}
+
+// feedIsFetched reports whether a feed with the given status is still
+// being kept up to date.
+func feedIsFetched(status string) bool {
+	return status == feedStatusActive || status == feedStatusErroring
+}
+
+// feedIsDead reports whether a feed's failure history says it is not
+// coming back.
+func feedIsDead(feed database.Feed, now time.Time) bool {
+	return feed.ConsecutiveFailures >= deadFeedFailures &&
+		feed.FailingSince.Valid && now.Sub(feed.FailingSince.Time) >= deadFeedAfter
+}
+
+// deactivateFeed marks a feed dead so it is no longer fetched, keeping
+// the error that killed it for its followers to see. Only active and
+// erroring feeds are deactivated: a feed its owner paused during the
+// fetch stays paused.
+func (cfg *ApiConfig) deactivateFeed(ctx context.Context, feed database.Feed, fetchErr error, status sql.NullInt32) {
+	_, err := cfg.DB.DeactivateFeed(ctx, database.DeactivateFeedParams{
+		ID:             feed.ID,
+		LastFetchError: sql.NullString{String: fetchErr.Error(), Valid: true},
+		LastHttpStatus: status,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		log.Printf("feed %s (%s) looks dead but is no longer fetched, leaving it: %v", feed.ID, feed.Url, fetchErr)
+		return
+	}
+	if err != nil {
+		log.Printf("failed to deactivate feed %s: %v", feed.ID, err)
+		return
+	}
+	log.Printf("feed %s (%s) is dead, deactivated it: %v", feed.ID, feed.Url, fetchErr)
+}
+
+// handleFeedStatusPut lets a feed's owner pause it or make it active
+// again, which also gives a dead feed a fresh start.
+func (cfg *ApiConfig) handleFeedStatusPut(w http.ResponseWriter, r *http.Request, user database.User) {
+	body := FeedStatusParams{}
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&body); err != nil {
+		internal.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var feed database.Feed
+	var err error
+	switch body.Status {
+	case feedStatusPaused:
+		feed, err = cfg.DB.PauseFeed(r.Context(), database.PauseFeedParams{ID: r.PathValue("feedID"), UserID: user.ID})
+	case feedStatusActive:
+		feed, err = cfg.DB.ReactivateFeed(r.Context(), database.ReactivateFeedParams{ID: r.PathValue("feedID"), UserID: user.ID})
+	default:
+		internal.RespondWithError(w, http.StatusBadRequest, `status must be "active" or "paused"`)
+		return
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		internal.RespondWithError(w, http.StatusNotFound, "feed not found")
+		return
+	}
+	if err != nil {
+		internal.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	payload := FeedParams{}
+	internal.RespondWithJSON(w, http.StatusOK, payload.asJSON(feed))
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rowinf/blog-aggregator/internal/database"
+)
+
+func TestFeedIsDead(t *testing.T) {
+	now := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		failures     int32
+		failingSince sql.NullTime
+		want         bool
+	}{
+		{"healthy", 0, sql.NullTime{}, false},
+		{"failing for weeks", 20, sql.NullTime{Time: now.Add(-deadFeedAfter), Valid: true}, true},
+		{"many failures but recent", 20, sql.NullTime{Time: now.Add(-3 * 24 * time.Hour), Valid: true}, false},
+		{"old but few failures", 3, sql.NullTime{Time: now.Add(-30 * 24 * time.Hour), Valid: true}, false},
+		{"no failure start recorded", 20, sql.NullTime{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed := database.Feed{ConsecutiveFailures: tt.failures, FailingSince: tt.failingSince}
+			if got := feedIsDead(feed, now); got != tt.want {
+				t.Errorf("feedIsDead() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleFeedStatusPutRejectsOtherStatuses(t *testing.T) {
+	cfg := &ApiConfig{}
+	for _, status := range []string{feedStatusDead, feedStatusErroring, feedStatusMerged, ""} {
+		body := strings.NewReader(`{"status": "` + status + `"}`)
+		req := httptest.NewRequest(http.MethodPut, "/v1/feeds/feed-1/status", body)
+		w := httptest.NewRecorder()
+		cfg.handleFeedStatusPut(w, req, database.User{ID: "user-1"})
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("status %q: got %d, want %d", status, w.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// HTTPStatusError reports a feed server answering with a non-2xx status.
+type HTTPStatusError struct {
+	StatusCode int
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// CacheValidators are the HTTP validators a feed server sent with its last
+// full response, replayed as If-None-Match and If-Modified-Since.
+type CacheValidators struct {
+	ETag         string
+	LastModified string
+}
+
+// FetchResult is the outcome of a feed fetch. StatusCode is set whenever the
+// server answered, even if the fetch failed afterwards, and URL is where
+// the response came from after following the Redirects. When NotModified is
+// true RSS is empty and Validators carries the ones sent with the request
+// unless the server refreshed them.
+type FetchResult struct {
+	RSS         RSS
+	URL         string
+	Redirects   []Redirect
+	StatusCode  int
+	ContentType string
+	NotModified bool
+	Validators  CacheValidators
+}
+
+func FetchFeed(ctx context.Context, url string, validators CacheValidators) (FetchResult, error) {
+	result, body, err := fetchDocument(ctx, url, validators)
+	if err != nil || result.NotModified {
+		return result, err
+	}
+	result.RSS, err = ParseFeed(result.ContentType, body)
+	if err != nil {
+		return result, fmt.Errorf("failed to parse feed: %w", err)
+	}
+	return result, nil
+}
+
+// fetchDocument performs the conditional GET behind FetchFeed and returns
+// the response body without interpreting it.
+func fetchDocument(ctx context.Context, url string, validators CacheValidators) (FetchResult, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return FetchResult{}, nil, err
+	}
+	if validators.ETag != "" {
+		req.Header.Set("If-None-Match", validators.ETag)
+	}
+	if validators.LastModified != "" {
+		req.Header.Set("If-Modified-Since", validators.LastModified)
+	}
+	resp, err := fetcher.Do(req)
+	if err != nil {
+		return FetchResult{}, nil, fmt.Errorf("couldnt fetch: %w", err)
+	}
+	defer resp.Body.Close()
+	result := FetchResult{
+		URL:         resp.Request.URL.String(),
+		Redirects:   redirectChain(resp),
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Validators: CacheValidators{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		},
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		result.NotModified = true
+		if result.Validators.ETag == "" {
+			result.Validators.ETag = validators.ETag
+		}
+		if result.Validators.LastModified == "" {
+			result.Validators.LastModified = validators.LastModified
+		}
+		return result, nil, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return result, nil, &HTTPStatusError{StatusCode: resp.StatusCode}
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return result, nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	return result, body, nil
+}
+
+func FetchRSSFeed(ctx context.Context, url string) (RSS, error) {
+	result, err := FetchFeed(ctx, url, CacheValidators{})
+	return result.RSS, err
+}
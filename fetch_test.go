@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFetchRSSFeed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(rssFixture))
+	}))
+	defer server.Close()
+
+	response, err := FetchRSSFeed(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("FetchRSSFeed returned an error: %v", err)
+	}
+	want := "Boot.dev Blog"
+	if want != response.Channel.Title {
+		t.Fatalf(`FetchRSSFeed(context.Background(), url) = %q, want match for %#q, nil`, response.Channel.Title, want)
+	}
+}
+
+func TestFetchRSSFeedServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	_, err := FetchRSSFeed(context.Background(), server.URL)
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("FetchRSSFeed error = %v, want HTTPStatusError", err)
+	}
+	if statusErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", statusErr.StatusCode, http.StatusInternalServerError)
+	}
+}
+
+func TestFetchRSSFeedTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	config := testFetcherConfig()
+	config.Timeout = 50 * time.Millisecond
+	withFetcher(t, config)
+
+	if _, err := FetchRSSFeed(context.Background(), server.URL); err == nil {
+		t.Fatal("FetchRSSFeed should time out against a server that never responds")
+	}
+}
+
+func TestFetchRSSFeedMalformedXML(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(`<rss><channel><title>Broken</title><item></channel>`))
+	}))
+	defer server.Close()
+
+	if _, err := FetchRSSFeed(context.Background(), server.URL); err == nil {
+		t.Fatal("FetchRSSFeed should return an error for malformed XML")
+	}
+}
+
+func TestFetchRSSFeedUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	if _, err := FetchRSSFeed(context.Background(), url); err == nil {
+		t.Fatal("FetchRSSFeed should return an error when the server is unreachable")
+	}
+}
+
+func TestFetchFeedConditionalGet(t *testing.T) {
+	const etag = `"v1"`
+	const lastModified = "Fri, 26 Jul 2024 00:00:00 GMT"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag && r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastModified)
+		w.Write([]byte(rssFixture))
+	}))
+	defer server.Close()
+
+	first, err := FetchFeed(context.Background(), server.URL, CacheValidators{})
+	if err != nil {
+		t.Fatalf("FetchFeed returned an error: %v", err)
+	}
+	if first.NotModified || first.RSS.Channel.Title != "Boot.dev Blog" {
+		t.Fatalf("first fetch = %+v, want full response", first)
+	}
+	if first.Validators.ETag != etag || first.Validators.LastModified != lastModified {
+		t.Fatalf("validators = %+v", first.Validators)
+	}
+
+	second, err := FetchFeed(context.Background(), server.URL, first.Validators)
+	if err != nil {
+		t.Fatalf("FetchFeed returned an error: %v", err)
+	}
+	if !second.NotModified || second.StatusCode != http.StatusNotModified {
+		t.Fatalf("second fetch = %+v, want 304", second)
+	}
+	if len(second.RSS.Channel.Items) != 0 {
+		t.Errorf("a 304 should not be parsed, got %d items", len(second.RSS.Channel.Items))
+	}
+	if second.Validators != first.Validators {
+		t.Errorf("validators = %+v, want them carried over from the request", second.Validators)
+	}
+}
+
+func TestFetchFeedCancelled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	_, err := FetchFeed(ctx, server.URL, CacheValidators{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("FetchFeed error = %v, want context.Canceled", err)
+	}
+}
+
+func TestFetchRSSFeedLegacyCharset(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml; charset=ISO-8859-1")
+		w.Write(latin1Feed(""))
+	}))
+	defer server.Close()
+
+	rss, err := FetchRSSFeed(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("FetchRSSFeed returned an error: %v", err)
+	}
+	if rss.Channel.Title != "Café" {
+		t.Errorf("title = %q, want %q", rss.Channel.Title, "Café")
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultUserAgent identifies the aggregator to feed servers, with a link
+// operators can follow to find out who is polling them.
+const defaultUserAgent = "blog-aggregator/1.0 (+https://github.com/rowinf/blog-aggregator)"
+
+// errBodyTooLarge is returned while reading a response whose body, once
+// decompressed, exceeds FetcherConfig.MaxBodySize.
+var errBodyTooLarge = errors.New("response body too large")
+
+// FetcherConfig controls the HTTP client every feed fetch goes through.
+type FetcherConfig struct {
+	// Timeout bounds a whole fetch, from dialing to reading the last byte.
+	Timeout time.Duration
+	// ConnectTimeout bounds dialing and the TLS handshake.
+	ConnectTimeout time.Duration
+	// ReadTimeout bounds the wait for response headers once the request
+	// has been sent.
+	ReadTimeout time.Duration
+	// MaxBodySize caps the decompressed size of a response body in bytes.
+	MaxBodySize int64
+	// UserAgent is sent with every request.
+	UserAgent string
+	// MaxRedirects is how many redirects a fetch follows before failing.
+	MaxRedirects int
+	// AllowedHosts are host names, IP addresses and CIDR ranges that may be
+	// fetched even though they are inside our network, for internal feeds.
+	AllowedHosts []string
+}
+
+func defaultFetcherConfig() FetcherConfig {
+	return FetcherConfig{
+		Timeout:        30 * time.Second,
+		ConnectTimeout: 10 * time.Second,
+		ReadTimeout:    20 * time.Second,
+		MaxBodySize:    10 << 20,
+		UserAgent:      defaultUserAgent,
+		MaxRedirects:   5,
+	}
+}
+
+func fetcherConfigFromEnv() FetcherConfig {
+	config := defaultFetcherConfig()
+	if timeout, err := time.ParseDuration(os.Getenv("FETCH_TIMEOUT")); err == nil && timeout > 0 {
+		config.Timeout = timeout
+	}
+	if timeout, err := time.ParseDuration(os.Getenv("FETCH_CONNECT_TIMEOUT")); err == nil && timeout > 0 {
+		config.ConnectTimeout = timeout
+	}
+	if timeout, err := time.ParseDuration(os.Getenv("FETCH_READ_TIMEOUT")); err == nil && timeout > 0 {
+		config.ReadTimeout = timeout
+	}
+	if size, err := strconv.ParseInt(os.Getenv("FETCH_MAX_BODY_BYTES"), 10, 64); err == nil && size > 0 {
+		config.MaxBodySize = size
+	}
+	if userAgent := strings.TrimSpace(os.Getenv("FETCH_USER_AGENT")); userAgent != "" {
+		config.UserAgent = userAgent
+	}
+	if redirects, err := strconv.Atoi(os.Getenv("FETCH_MAX_REDIRECTS")); err == nil && redirects >= 0 {
+		config.MaxRedirects = redirects
+	}
+	for _, host := range strings.Split(os.Getenv("FETCH_ALLOWED_HOSTS"), ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			config.AllowedHosts = append(config.AllowedHosts, host)
+		}
+	}
+	return config
+}
+
+// fetcher is shared by every outgoing request to feed servers and hubs so
+// that a hung or hostile server cannot block a worker forever or exhaust
+// memory.
+var fetcher = newFeedFetcher(defaultFetcherConfig())
+
+// feedFetcher is an HTTP client with the limits of a FetcherConfig.
+// Responses come back with their body decompressed and size limited.
+// Feed URLs come from users, so it refuses to connect to addresses inside
+// our network unless they are allowlisted.
+type feedFetcher struct {
+	config FetcherConfig
+	policy addressPolicy
+	client *http.Client
+}
+
+func newFeedFetcher(config FetcherConfig) *feedFetcher {
+	policy := newAddressPolicy(config.AllowedHosts)
+	guarded := &net.Dialer{Timeout: config.ConnectTimeout, KeepAlive: 30 * time.Second, Control: policy.control}
+	unguarded := &net.Dialer{Timeout: config.ConnectTimeout, KeepAlive: 30 * time.Second}
+	// There is no proxy: the guard has to see the feed server's address,
+	// not the proxy's.
+	transport := &http.Transport{
+		DialContext:           guardedDialer(policy, guarded, unguarded),
+		TLSHandshakeTimeout:   config.ConnectTimeout,
+		ResponseHeaderTimeout: config.ReadTimeout,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		ForceAttemptHTTP2:     true,
+	}
+	return &feedFetcher{
+		config: config,
+		policy: policy,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   config.Timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) > config.MaxRedirects {
+					return fmt.Errorf("stopped after %d redirects", config.MaxRedirects)
+				}
+				return policy.checkURL(req.URL)
+			},
+		},
+	}
+}
+
+// Do sends req with the configured User-Agent, asking for a compressed
+// response, and decodes the body it gets back. Requests for internal
+// addresses fail with errBlockedAddress.
+func (f *feedFetcher) Do(req *http.Request) (*http.Response, error) {
+	if err := f.policy.checkURL(req.URL); err != nil {
+		return nil, err
+	}
+	if f.config.UserAgent != "" {
+		req.Header.Set("User-Agent", f.config.UserAgent)
+	}
+	// Setting Accept-Encoding ourselves turns off the transport's
+	// transparent gzip, which does not cover deflate.
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if f.config.MaxBodySize > 0 && resp.ContentLength > f.config.MaxBodySize &&
+		resp.Header.Get("Content-Encoding") == "" {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %d bytes", errBodyTooLarge, resp.ContentLength)
+	}
+	body, err := decodeBody(resp.Header.Get("Content-Encoding"), resp.Body)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if body != resp.Body {
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = -1
+		resp.Uncompressed = true
+	}
+	if f.config.MaxBodySize > 0 {
+		body = &limitedBody{reader: body, remaining: f.config.MaxBodySize}
+	}
+	resp.Body = readCloser{Reader: body, Closer: resp.Body}
+	return resp, nil
+}
+
+// Redirect is one hop of a redirect chain followed during a fetch.
+type Redirect struct {
+	From       string
+	To         string
+	StatusCode int
+}
+
+// redirectChain lists the redirects that led to resp, in the order they
+// were followed.
+func redirectChain(resp *http.Response) []Redirect {
+	var chain []Redirect
+	for req := resp.Request; req != nil && req.Response != nil; req = req.Response.Request {
+		chain = append(chain, Redirect{
+			From:       req.Response.Request.URL.String(),
+			To:         req.URL.String(),
+			StatusCode: req.Response.StatusCode,
+		})
+	}
+	slices.Reverse(chain)
+	return chain
+}
+
+// permanentRedirectTarget returns where the permanent redirects at the
+// start of a chain lead, or "" when the first hop is temporary. A
+// temporary hop later on does not undo the permanent ones before it.
+func permanentRedirectTarget(chain []Redirect) string {
+	target := ""
+	for _, redirect := range chain {
+		if redirect.StatusCode != http.StatusMovedPermanently && redirect.StatusCode != http.StatusPermanentRedirect {
+			break
+		}
+		target = redirect.To
+	}
+	return target
+}
+
+// decodeBody undoes a Content-Encoding. HTTP deflate is meant to be
+// zlib-wrapped, but some servers send raw deflate data, so both are read.
+func decodeBody(encoding string, body io.Reader) (io.Reader, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", "identity":
+		return body, nil
+	case "gzip", "x-gzip":
+		reader, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip response: %w", err)
+		}
+		return reader, nil
+	case "deflate":
+		buffered := bufio.NewReader(body)
+		header, err := buffered.Peek(2)
+		if err == nil && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 && header[0]&0x0f == 8 {
+			reader, err := zlib.NewReader(buffered)
+			if err != nil {
+				return nil, fmt.Errorf("invalid deflate response: %w", err)
+			}
+			return reader, nil
+		}
+		return flate.NewReader(buffered), nil
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+}
+
+// limitedBody fails with errBodyTooLarge instead of silently truncating
+// once more than remaining bytes have been read.
+type limitedBody struct {
+	reader    io.Reader
+	remaining int64
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, errBodyTooLarge
+	}
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.reader.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n, errBodyTooLarge
+	}
+	return n, err
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testFetcherConfig is the default config with loopback allowlisted, as
+// every test server listens on 127.0.0.1.
+func testFetcherConfig() FetcherConfig {
+	config := defaultFetcherConfig()
+	config.AllowedHosts = []string{"127.0.0.1"}
+	return config
+}
+
+func TestMain(m *testing.M) {
+	fetcher = newFeedFetcher(testFetcherConfig())
+	os.Exit(m.Run())
+}
+
+// withFetcher swaps the shared fetcher for one built from config for the
+// rest of the test.
+func withFetcher(t *testing.T, config FetcherConfig) {
+	original := fetcher
+	fetcher = newFeedFetcher(config)
+	t.Cleanup(func() { fetcher = original })
+}
+
+func TestFetcherUserAgent(t *testing.T) {
+	agents := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents <- r.Header.Get("User-Agent")
+		w.Write([]byte(rssFixture))
+	}))
+	defer server.Close()
+
+	if _, err := FetchRSSFeed(context.Background(), server.URL); err != nil {
+		t.Fatalf("FetchRSSFeed returned an error: %v", err)
+	}
+	if got := <-agents; got != defaultUserAgent {
+		t.Errorf("User-Agent = %q, want %q", got, defaultUserAgent)
+	}
+
+	config := testFetcherConfig()
+	config.UserAgent = "custom-agent/2.0"
+	withFetcher(t, config)
+	if _, err := FetchRSSFeed(context.Background(), server.URL); err != nil {
+		t.Fatalf("FetchRSSFeed returned an error: %v", err)
+	}
+	if got := <-agents; got != "custom-agent/2.0" {
+		t.Errorf("User-Agent = %q, want the configured one", got)
+	}
+}
+
+func TestFetcherContentEncoding(t *testing.T) {
+	compress := map[string]func(io.Writer) io.WriteCloser{
+		"gzip":    func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		"deflate": func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+		"raw deflate": func(w io.Writer) io.WriteCloser {
+			writer, _ := flate.NewWriter(w, flate.DefaultCompression)
+			return writer
+		},
+	}
+	for name, newWriter := range compress {
+		var body bytes.Buffer
+		writer := newWriter(&body)
+		writer.Write([]byte(rssFixture))
+		writer.Close()
+		encoding := strings.TrimPrefix(name, "raw ")
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.Contains(r.Header.Get("Accept-Encoding"), encoding) {
+				t.Errorf("%s: Accept-Encoding = %q", name, r.Header.Get("Accept-Encoding"))
+			}
+			w.Header().Set("Content-Encoding", encoding)
+			w.Write(body.Bytes())
+		}))
+		rss, err := FetchRSSFeed(context.Background(), server.URL)
+		server.Close()
+		if err != nil {
+			t.Errorf("%s: FetchRSSFeed returned an error: %v", name, err)
+			continue
+		}
+		if rss.Channel.Title != "Boot.dev Blog" {
+			t.Errorf("%s: title = %q", name, rss.Channel.Title)
+		}
+	}
+}
+
+func TestFetcherMaxBodySize(t *testing.T) {
+	big := strings.Repeat("x", 4096)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/declared":
+			w.Header().Set("Content-Length", strconv.Itoa(len(big)))
+			w.Write([]byte(big))
+		case "/chunked":
+			w.Write([]byte(big[:2048]))
+			w.(http.Flusher).Flush()
+			w.Write([]byte(big[2048:]))
+		case "/gzip":
+			// Small on the wire, large once decompressed.
+			w.Header().Set("Content-Encoding", "gzip")
+			writer := gzip.NewWriter(w)
+			writer.Write([]byte(big))
+			writer.Close()
+		}
+	}))
+	defer server.Close()
+
+	config := testFetcherConfig()
+	config.MaxBodySize = 1024
+	withFetcher(t, config)
+	for _, path := range []string{"/declared", "/chunked", "/gzip"} {
+		_, err := FetchRSSFeed(context.Background(), server.URL+path)
+		if !errors.Is(err, errBodyTooLarge) {
+			t.Errorf("%s: error = %v, want errBodyTooLarge", path, err)
+		}
+	}
+}
+
+func TestFetcherRedirectLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hops, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/"))
+		if hops > 0 {
+			http.Redirect(w, r, "/"+strconv.Itoa(hops-1), http.StatusFound)
+			return
+		}
+		w.Write([]byte(rssFixture))
+	}))
+	defer server.Close()
+
+	config := testFetcherConfig()
+	config.MaxRedirects = 2
+	withFetcher(t, config)
+	if _, err := FetchRSSFeed(context.Background(), server.URL+"/2"); err != nil {
+		t.Errorf("FetchRSSFeed with 2 redirects returned an error: %v", err)
+	}
+	if _, err := FetchRSSFeed(context.Background(), server.URL+"/3"); err == nil {
+		t.Error("FetchRSSFeed should give up after 2 redirects")
+	}
+}
+
+func TestFetchFeedRedirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/moved", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusPermanentRedirect)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/today", http.StatusFound)
+	})
+	mux.HandleFunc("/today", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(rssFixture))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	result, err := FetchFeed(context.Background(), server.URL+"/old", CacheValidators{})
+	if err != nil {
+		t.Fatalf("FetchFeed returned an error: %v", err)
+	}
+	want := []Redirect{
+		{From: server.URL + "/old", To: server.URL + "/moved", StatusCode: http.StatusMovedPermanently},
+		{From: server.URL + "/moved", To: server.URL + "/new", StatusCode: http.StatusPermanentRedirect},
+		{From: server.URL + "/new", To: server.URL + "/today", StatusCode: http.StatusFound},
+	}
+	if !slices.Equal(result.Redirects, want) {
+		t.Fatalf("Redirects = %+v, want %+v", result.Redirects, want)
+	}
+	if got := permanentRedirectTarget(result.Redirects); got != server.URL+"/new" {
+		t.Errorf("permanentRedirectTarget = %q, want the end of the permanent hops", got)
+	}
+
+	result, err = FetchFeed(context.Background(), server.URL+"/today", CacheValidators{})
+	if err != nil {
+		t.Fatalf("FetchFeed returned an error: %v", err)
+	}
+	if len(result.Redirects) != 0 {
+		t.Errorf("Redirects = %+v, want none", result.Redirects)
+	}
+}
+
+func TestPermanentRedirectTarget(t *testing.T) {
+	tests := []struct {
+		name  string
+		chain []Redirect
+		want  string
+	}{
+		{"no redirects", nil, ""},
+		{"temporary first", []Redirect{
+			{From: "a", To: "b", StatusCode: http.StatusFound},
+			{From: "b", To: "c", StatusCode: http.StatusMovedPermanently},
+		}, ""},
+		{"permanent", []Redirect{
+			{From: "a", To: "b", StatusCode: http.StatusMovedPermanently},
+		}, "b"},
+		{"temporary 307", []Redirect{
+			{From: "a", To: "b", StatusCode: http.StatusTemporaryRedirect},
+		}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := permanentRedirectTarget(tt.chain); got != tt.want {
+				t.Errorf("permanentRedirectTarget() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchFeedGone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer server.Close()
+
+	_, err := FetchFeed(context.Background(), server.URL, CacheValidators{})
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusGone {
+		t.Errorf("FetchFeed error = %v, want an HTTPStatusError with 410", err)
+	}
+}
+
+func TestFetcherReadTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	config := testFetcherConfig()
+	config.ReadTimeout = 50 * time.Millisecond
+	withFetcher(t, config)
+	start := time.Now()
+	if _, err := FetchRSSFeed(context.Background(), server.URL); err == nil {
+		t.Fatal("FetchRSSFeed should time out waiting for response headers")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("fetch took %v, want the read timeout to apply", elapsed)
+	}
+}
+
+func TestFetcherConfigFromEnv(t *testing.T) {
+	t.Setenv("FETCH_TIMEOUT", "45s")
+	t.Setenv("FETCH_CONNECT_TIMEOUT", "3s")
+	t.Setenv("FETCH_READ_TIMEOUT", "bogus")
+	t.Setenv("FETCH_MAX_BODY_BYTES", "2048")
+	t.Setenv("FETCH_USER_AGENT", "test-agent")
+	t.Setenv("FETCH_MAX_REDIRECTS", "0")
+	t.Setenv("FETCH_ALLOWED_HOSTS", "feeds.internal, 10.1.0.0/16,")
+
+	config := fetcherConfigFromEnv()
+	want := defaultFetcherConfig()
+	want.Timeout = 45 * time.Second
+	want.ConnectTimeout = 3 * time.Second
+	want.MaxBodySize = 2048
+	want.UserAgent = "test-agent"
+	want.MaxRedirects = 0
+	want.AllowedHosts = []string{"feeds.internal", "10.1.0.0/16"}
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("fetcherConfigFromEnv = %+v, want %+v", config, want)
+	}
+}
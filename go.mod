@@ -1,15 +1,12 @@
 module github.com/rowinf/blog-aggregator
 
-go 1.22.2
-
-require github.com/joho/godotenv v1.5.1
+go 1.23.0
 
 require (
-	github.com/google/uuid v1.6.0 // indirect
-	github.com/jackc/pgpassfile v1.0.0 // indirect
-	github.com/jackc/pgservicefile v0.0.0-20221227161230-091c0ba34f0a // indirect
-	github.com/jackc/pgx/v5 v5.6.0 // indirect
-	github.com/lib/pq v1.10.9 // indirect
-	golang.org/x/crypto v0.17.0 // indirect
-	golang.org/x/text v0.14.0 // indirect
+	github.com/google/uuid v1.6.0
+	github.com/joho/godotenv v1.5.1
+	github.com/lib/pq v1.10.9
+	golang.org/x/net v0.38.0
 )
+
+require golang.org/x/text v0.23.0 // indirect
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rowinf/blog-aggregator/internal"
+	"github.com/rowinf/blog-aggregator/internal/database"
+)
+
+// excerptLength is the most characters a post excerpt keeps before it is
+// cut off.
+const excerptLength = 300
+
+// ingestPosts upserts a feed's items into posts. Items already stored are
+// only rewritten when their content changed, so updated_at tracks real edits.
+// Items that fail are logged and skipped; the returned error says whether
+// any were, or whether ctx stopped ingestion partway.
+func (cfg *ApiConfig) ingestPosts(ctx context.Context, feedID string, channel Channel) error {
+	fetchedAt := time.Now()
+	failed := 0
+	for _, item := range channel.Items {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		guid := postGUID(item)
+		if guid == "" {
+			log.Printf("skipping item without guid or link in feed %s: %q", feedID, item.Title)
+			continue
+		}
+		publishedDate, err := ParseDate(item.PubDate)
+		if err != nil {
+			// Undated or unparseable items are treated as published when we
+			// first saw them rather than dropped.
+			if !errors.Is(err, errEmptyDate) {
+				log.Printf("date error in feed %s: %v", feedID, err)
+			}
+			publishedDate = fetchedAt
+		}
+		base := postBaseURL(channel, item)
+		description := internal.SanitizeHTML(item.Description, base)
+		content := internal.SanitizeHTML(item.Content, base)
+		revision := database.CreatePostRevisionParams{
+			ID:          uuid.NewString(),
+			CreatedAt:   time.Now(),
+			FeedID:      feedID,
+			Guid:        guid,
+			Title:       item.Title,
+			Url:         item.Link,
+			Description: description,
+			Content:     content,
+			// The raw text tells edits from posts stored before sanitization.
+			RawDescription: item.Description,
+			RawContent:     item.Content,
+		}
+		// Posts stored before guids existed were given their URL as guid;
+		// savePost takes such a post over if this one turns out to be new.
+		legacyURL := ""
+		if item.Link != guid {
+			legacyURL = item.Link
+		}
+		imageURL := postImageURL(item)
+		err = cfg.savePost(ctx, revision, legacyURL, database.UpsertPostParams{
+			ID:             uuid.NewString(),
+			CreatedAt:      time.Now(),
+			UpdatedAt:      time.Now(),
+			Title:          item.Title,
+			Url:            item.Link,
+			Description:    description,
+			PublishedAt:    publishedDate,
+			FeedID:         feedID,
+			Guid:           guid,
+			Content:        content,
+			Excerpt:        postExcerpt(description, content),
+			ImageUrl:       sql.NullString{String: imageURL, Valid: imageURL != ""},
+			Author:         postAuthor(item),
+			RawDescription: item.Description,
+			RawContent:     item.Content,
+		})
+		if err != nil {
+			log.Printf("couldnt save post %q in feed %s: %v", guid, feedID, err)
+			failed++
+			continue
+		}
+		if err := cfg.savePostCategories(ctx, feedID, guid, postCategories(item)); err != nil {
+			log.Printf("couldnt save categories of post %q in feed %s: %v", guid, feedID, err)
+		}
+		if err := cfg.savePostAuthors(ctx, feedID, guid, postAuthors(item)); err != nil {
+			log.Printf("couldnt save authors of post %q in feed %s: %v", guid, feedID, err)
+		}
+		for _, enclosure := range enclosureParams(channel, item, feedID, guid) {
+			if err := cfg.DB.UpsertPostEnclosure(ctx, enclosure); err != nil {
+				log.Printf("couldnt save enclosure %q of post %q in feed %s: %v", enclosure.Url, guid, feedID, err)
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d items could not be saved", failed, len(channel.Items))
+	}
+	return nil
+}
+
+// savePost keeps the stored version of a post as a revision and upserts
+// the new one in a single transaction, so a failed upsert cannot leave a
+// revision behind that the next fetch would record again. When the upsert
+// inserts a new post and legacyURL is set, a post stored under legacyURL
+// before guids existed is taken over instead of kept as a second copy;
+// this only costs a statement for posts the feed has not had before.
+//
+// Only the text a reader sees, title, url, description and content, makes
+// a revision. The upsert also rewrites a post whose image, author or
+// excerpt changed, but those are not versioned, so such an edit updates
+// the post without adding a revision with an empty diff.
+func (cfg *ApiConfig) savePost(ctx context.Context, revision database.CreatePostRevisionParams, legacyURL string, post database.UpsertPostParams) error {
+	tx, err := cfg.DBConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	queries := cfg.DB.WithTx(tx)
+
+	inserted, err := upsertPostWithRevision(ctx, queries, revision, post)
+	if err != nil {
+		return err
+	}
+	if inserted && legacyURL != "" {
+		adopted, err := queries.AdoptLegacyPost(ctx, database.AdoptLegacyPostParams{ID: post.ID, Url: legacyURL})
+		if err != nil {
+			return fmt.Errorf("couldnt adopt url-keyed post: %w", err)
+		}
+		// Save again so the adopted post gets this version.
+		if adopted > 0 {
+			if _, err := upsertPostWithRevision(ctx, queries, revision, post); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// upsertPostWithRevision runs the revision and upsert statements of
+// savePost and reports whether the upsert inserted a new post.
+func upsertPostWithRevision(ctx context.Context, queries *database.Queries, revision database.CreatePostRevisionParams, post database.UpsertPostParams) (bool, error) {
+	// Keep the stored version before the upsert overwrites it; this is a
+	// no-op for new posts and unchanged ones.
+	if err := queries.CreatePostRevision(ctx, revision); err != nil {
+		return false, fmt.Errorf("couldnt save revision: %w", err)
+	}
+	saved, err := queries.UpsertPost(ctx, post)
+	// No row comes back when the stored post is already up to date.
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return saved.ID == post.ID, nil
+}
+
+// postGUID identifies an item within its feed: the feed's own guid/id when
+// it has one, otherwise the item's link.
+func postGUID(item Item) string {
+	if guid := strings.TrimSpace(item.GUID); guid != "" {
+		return guid
+	}
+	return strings.TrimSpace(item.Link)
+}
+
+// postBaseURL is the URL relative links in an item's HTML are resolved
+// against: the item's link, itself resolved against the channel link.
+func postBaseURL(channel Channel, item Item) *url.URL {
+	base, err := url.Parse(strings.TrimSpace(channel.Link))
+	if err != nil || !base.IsAbs() {
+		base = nil
+	}
+	link, err := url.Parse(strings.TrimSpace(item.Link))
+	if err != nil {
+		return base
+	}
+	if base != nil {
+		link = base.ResolveReference(link)
+	}
+	if !link.IsAbs() {
+		return nil
+	}
+	return link
+}
+
+// postExcerpt is a plain-text preview of a post, taken from its summary or,
+// failing that, its full content and cut at a word boundary.
+func postExcerpt(description, content string) string {
+	text := internal.PlainText(description)
+	if text == "" {
+		text = internal.PlainText(content)
+	}
+	runes := []rune(text)
+	if len(runes) <= excerptLength {
+		return text
+	}
+	cut := string(runes[:excerptLength])
+	if space := strings.LastIndex(cut, " "); space > 0 {
+		cut = cut[:space]
+	}
+	return strings.TrimRight(cut, " ,.;:") + "…"
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/rowinf/blog-aggregator/internal/database"
+)
+
+func TestPostGUID(t *testing.T) {
+	cases := []struct {
+		item Item
+		want string
+	}{
+		{Item{GUID: " tag:example.com,2024:1 ", Link: "https://example.com/1"}, "tag:example.com,2024:1"},
+		{Item{Link: "https://example.com/2"}, "https://example.com/2"},
+		{Item{Title: "no identity"}, ""},
+	}
+	for _, c := range cases {
+		if got := postGUID(c.item); got != c.want {
+			t.Errorf("postGUID(%+v) = %q, want %q", c.item, got, c.want)
+		}
+	}
+}
+
+func TestPostBaseURL(t *testing.T) {
+	cases := []struct {
+		channel, link, want string
+	}{
+		{"https://example.com/", "https://example.com/posts/1", "https://example.com/posts/1"},
+		{"https://example.com/blog/", "posts/1", "https://example.com/blog/posts/1"},
+		{"", "https://example.com/posts/1", "https://example.com/posts/1"},
+		{"https://example.com/", "", "https://example.com/"},
+		{"", "/posts/1", ""},
+	}
+	for _, c := range cases {
+		got := ""
+		if base := postBaseURL(Channel{Link: c.channel}, Item{Link: c.link}); base != nil {
+			got = base.String()
+		}
+		if got != c.want {
+			t.Errorf("postBaseURL(%q, %q) = %q, want %q", c.channel, c.link, got, c.want)
+		}
+	}
+}
+
+func TestPostExcerpt(t *testing.T) {
+	if got := postExcerpt("<p>Short <b>summary</b></p>", "<p>Long content</p>"); got != "Short summary" {
+		t.Errorf("excerpt = %q, want the summary as text", got)
+	}
+	if got := postExcerpt("", "<p>Only content</p>"); got != "Only content" {
+		t.Errorf("excerpt = %q, want the content fallback", got)
+	}
+
+	long := strings.Repeat("word ", 100)
+	got := postExcerpt(long, "")
+	if !strings.HasSuffix(got, "word…") {
+		t.Errorf("excerpt = %q, want it cut after a whole word", got)
+	}
+	if n := utf8.RuneCountInString(got); n > excerptLength+1 {
+		t.Errorf("excerpt has %d characters, want at most %d", n, excerptLength+1)
+	}
+}
+
+func TestIngestPostsAdoptsURLKeyedPosts(t *testing.T) {
+	channel := Channel{Items: []Item{
+		{GUID: "tag:example.com,2024:1", Link: "https://example.com/1", Title: "With guid"},
+		{Link: "https://example.com/2", Title: "Link only"},
+	}}
+
+	// Posts the feed already has are never looked up under their url.
+	cfg, db := newFakeDB(t)
+	cfg.ingestPosts(context.Background(), "feed-1", channel)
+	if calls := db.called(); slices.Contains(calls, "AdoptLegacyPost") {
+		t.Errorf("calls = %v, want no adoption when no post was inserted", calls)
+	}
+
+	// A newly inserted post takes over its url-keyed copy and is saved again.
+	cfg, db = newFakeDB(t)
+	db.answers("UpsertPost", func(args []driver.Value) []any {
+		return []any{database.Post{ID: args[0].(string), Guid: args[8].(string)}}
+	})
+	cfg.ingestPosts(context.Background(), "feed-1", channel)
+	call, ok := db.call("AdoptLegacyPost")
+	if !ok {
+		t.Fatalf("AdoptLegacyPost was not run; calls: %v", db.called())
+	}
+	upsert, _ := db.call("UpsertPost")
+	if want := []driver.Value{upsert.Args[0], "https://example.com/1"}; !slices.Equal(call.Args, want) {
+		t.Errorf("AdoptLegacyPost args = %v, want %v", call.Args, want)
+	}
+	calls := db.called()
+	want := []string{"BEGIN", "CreatePostRevision", "UpsertPost", "AdoptLegacyPost", "CreatePostRevision", "UpsertPost", "COMMIT"}
+	if len(calls) < len(want) || !slices.Equal(calls[:len(want)], want) {
+		t.Errorf("calls = %v, want them to start with %v", calls, want)
+	}
+	adoptions := 0
+	for _, name := range calls {
+		if name == "AdoptLegacyPost" {
+			adoptions++
+		}
+	}
+	if adoptions != 1 {
+		t.Errorf("AdoptLegacyPost ran %d times, want once: an item keyed by its link is already stored that way", adoptions)
+	}
+}
+
+func TestIngestPostsRollsBackRevisionWhenUpsertFails(t *testing.T) {
+	cfg, db := newFakeDB(t)
+	db.fails("UpsertPost", errors.New("connection reset"))
+	channel := Channel{Items: []Item{{GUID: "https://example.com/1", Link: "https://example.com/1", Title: "Edited"}}}
+	if err := cfg.ingestPosts(context.Background(), "feed-1", channel); err == nil {
+		t.Error("ingestPosts returned no error for an item it could not save")
+	}
+	want := []string{"BEGIN", "CreatePostRevision", "UpsertPost", "ROLLBACK"}
+	if calls := db.called(); !slices.Equal(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestIngestPostsPassesRawTextForPreSanitizationPosts(t *testing.T) {
+	cfg, db := newFakeDB(t)
+	raw := `<p onclick="track()">Hello</p>`
+	channel := Channel{Items: []Item{{GUID: "https://example.com/1", Link: "https://example.com/1", Description: raw}}}
+	cfg.ingestPosts(context.Background(), "feed-1", channel)
+
+	for _, name := range []string{"CreatePostRevision", "UpsertPost"} {
+		call, ok := db.call(name)
+		if !ok {
+			t.Fatalf("%s was not run; calls: %v", name, db.called())
+		}
+		if !slices.Contains(call.Args, driver.Value("<p>Hello</p>")) || !slices.Contains(call.Args, driver.Value(raw)) {
+			t.Errorf("%s args = %v, want both the sanitized and the raw description", name, call.Args)
+		}
+	}
+}
@@ -61,6 +61,62 @@ func (q *Queries) DeleteFeedFollow(ctx context.Context, id string) (FeedFollow,
 	return i, err
 }
 
+const deleteFeedFollowsByFeedId = `-- name: DeleteFeedFollowsByFeedId :many
+DELETE FROM feed_follows WHERE feed_id=$1
+RETURNING id, created_at, updated_at, user_id, feed_id
+`
+
+func (q *Queries) DeleteFeedFollowsByFeedId(ctx context.Context, feedID string) ([]FeedFollow, error) {
+	rows, err := q.db.QueryContext(ctx, deleteFeedFollowsByFeedId, feedID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FeedFollow
+	for rows.Next() {
+		var i FeedFollow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.FeedID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeedFollow = `-- name: GetFeedFollow :one
+SELECT id, created_at, updated_at, user_id, feed_id FROM feed_follows WHERE user_id=$1 AND feed_id=$2
+`
+
+type GetFeedFollowParams struct {
+	UserID string
+	FeedID string
+}
+
+func (q *Queries) GetFeedFollow(ctx context.Context, arg GetFeedFollowParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedFollow, arg.UserID, arg.FeedID)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.FeedID,
+	)
+	return i, err
+}
+
 const getFeedFollowsByUserId = `-- name: GetFeedFollowsByUserId :many
 SELECT id, created_at, updated_at, user_id, feed_id FROM feed_follows WHERE user_id=$1
 `
@@ -93,3 +149,19 @@ func (q *Queries) GetFeedFollowsByUserId(ctx context.Context, userID string) ([]
 	}
 	return items, nil
 }
+
+const moveFeedFollows = `-- name: MoveFeedFollows :exec
+UPDATE feed_follows SET feed_id=$1, updated_at=NOW()
+WHERE feed_id=$2
+AND user_id NOT IN (SELECT ff.user_id FROM feed_follows as ff WHERE ff.feed_id=$1)
+`
+
+type MoveFeedFollowsParams struct {
+	ToFeedID   string
+	FromFeedID string
+}
+
+func (q *Queries) MoveFeedFollows(ctx context.Context, arg MoveFeedFollowsParams) error {
+	_, err := q.db.ExecContext(ctx, moveFeedFollows, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
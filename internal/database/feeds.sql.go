@@ -7,22 +7,26 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 )
 
 const createFeed = `-- name: CreateFeed :one
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, site_link, description, language)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, last_fetch_error, consecutive_failures, last_http_status, next_fetch_at, etag, last_modified, fetch_interval_seconds, site_link, description, language, redirect_url, redirect_count, status, failing_since, merged_into
 `
 
 type CreateFeedParams struct {
-	ID        string
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string
-	Url       string
-	UserID    string
+	ID          string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Name        string
+	Url         string
+	UserID      string
+	SiteLink    string
+	Description string
+	Language    string
 }
 
 func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error) {
@@ -33,6 +37,9 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		arg.Name,
 		arg.Url,
 		arg.UserID,
+		arg.SiteLink,
+		arg.Description,
+		arg.Language,
 	)
 	var i Feed
 	err := row.Scan(
@@ -43,12 +50,70 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.LastFetchError,
+		&i.ConsecutiveFailures,
+		&i.LastHttpStatus,
+		&i.NextFetchAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FetchIntervalSeconds,
+		&i.SiteLink,
+		&i.Description,
+		&i.Language,
+		&i.RedirectUrl,
+		&i.RedirectCount,
+		&i.Status,
+		&i.FailingSince,
+		&i.MergedInto,
+	)
+	return i, err
+}
+
+const deactivateFeed = `-- name: DeactivateFeed :one
+UPDATE feeds SET status='dead', last_fetched_at=NOW(), updated_at=NOW(), last_fetch_error=$2,
+last_http_status=$3, next_fetch_at=NULL
+WHERE id=$1 AND status IN ('active', 'erroring')
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, last_fetch_error, consecutive_failures, last_http_status, next_fetch_at, etag, last_modified, fetch_interval_seconds, site_link, description, language, redirect_url, redirect_count, status, failing_since, merged_into
+`
+
+type DeactivateFeedParams struct {
+	ID             string
+	LastFetchError sql.NullString
+	LastHttpStatus sql.NullInt32
+}
+
+func (q *Queries) DeactivateFeed(ctx context.Context, arg DeactivateFeedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, deactivateFeed, arg.ID, arg.LastFetchError, arg.LastHttpStatus)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.LastFetchError,
+		&i.ConsecutiveFailures,
+		&i.LastHttpStatus,
+		&i.NextFetchAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FetchIntervalSeconds,
+		&i.SiteLink,
+		&i.Description,
+		&i.Language,
+		&i.RedirectUrl,
+		&i.RedirectCount,
+		&i.Status,
+		&i.FailingSince,
+		&i.MergedInto,
 	)
 	return i, err
 }
 
 const getAllFeeds = `-- name: GetAllFeeds :many
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, last_fetch_error, consecutive_failures, last_http_status, next_fetch_at, etag, last_modified, fetch_interval_seconds, site_link, description, language, redirect_url, redirect_count, status, failing_since, merged_into FROM feeds
 `
 
 func (q *Queries) GetAllFeeds(ctx context.Context) ([]Feed, error) {
@@ -68,6 +133,21 @@ func (q *Queries) GetAllFeeds(ctx context.Context) ([]Feed, error) {
 			&i.Url,
 			&i.UserID,
 			&i.LastFetchedAt,
+			&i.LastFetchError,
+			&i.ConsecutiveFailures,
+			&i.LastHttpStatus,
+			&i.NextFetchAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.FetchIntervalSeconds,
+			&i.SiteLink,
+			&i.Description,
+			&i.Language,
+			&i.RedirectUrl,
+			&i.RedirectCount,
+			&i.Status,
+			&i.FailingSince,
+			&i.MergedInto,
 		); err != nil {
 			return nil, err
 		}
@@ -82,8 +162,78 @@ func (q *Queries) GetAllFeeds(ctx context.Context) ([]Feed, error) {
 	return items, nil
 }
 
+const getFeedByID = `-- name: GetFeedByID :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, last_fetch_error, consecutive_failures, last_http_status, next_fetch_at, etag, last_modified, fetch_interval_seconds, site_link, description, language, redirect_url, redirect_count, status, failing_since, merged_into FROM feeds
+WHERE id = $1
+`
+
+func (q *Queries) GetFeedByID(ctx context.Context, id string) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByID, id)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.LastFetchError,
+		&i.ConsecutiveFailures,
+		&i.LastHttpStatus,
+		&i.NextFetchAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FetchIntervalSeconds,
+		&i.SiteLink,
+		&i.Description,
+		&i.Language,
+		&i.RedirectUrl,
+		&i.RedirectCount,
+		&i.Status,
+		&i.FailingSince,
+		&i.MergedInto,
+	)
+	return i, err
+}
+
+const getFeedByURL = `-- name: GetFeedByURL :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, last_fetch_error, consecutive_failures, last_http_status, next_fetch_at, etag, last_modified, fetch_interval_seconds, site_link, description, language, redirect_url, redirect_count, status, failing_since, merged_into FROM feeds
+WHERE url = $1
+`
+
+func (q *Queries) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByURL, url)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.LastFetchError,
+		&i.ConsecutiveFailures,
+		&i.LastHttpStatus,
+		&i.NextFetchAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FetchIntervalSeconds,
+		&i.SiteLink,
+		&i.Description,
+		&i.Language,
+		&i.RedirectUrl,
+		&i.RedirectCount,
+		&i.Status,
+		&i.FailingSince,
+		&i.MergedInto,
+	)
+	return i, err
+}
+
 const getFeedsByUserId = `-- name: GetFeedsByUserId :many
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, last_fetch_error, consecutive_failures, last_http_status, next_fetch_at, etag, last_modified, fetch_interval_seconds, site_link, description, language, redirect_url, redirect_count, status, failing_since, merged_into FROM feeds
 WHERE user_id = $1
 `
 
@@ -104,6 +254,73 @@ func (q *Queries) GetFeedsByUserId(ctx context.Context, userID string) ([]Feed,
 			&i.Url,
 			&i.UserID,
 			&i.LastFetchedAt,
+			&i.LastFetchError,
+			&i.ConsecutiveFailures,
+			&i.LastHttpStatus,
+			&i.NextFetchAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.FetchIntervalSeconds,
+			&i.SiteLink,
+			&i.Description,
+			&i.Language,
+			&i.RedirectUrl,
+			&i.RedirectCount,
+			&i.Status,
+			&i.FailingSince,
+			&i.MergedInto,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeedsFollowedByUser = `-- name: GetFeedsFollowedByUser :many
+SELECT f.id, f.created_at, f.updated_at, f.name, f.url, f.user_id, f.last_fetched_at, f.last_fetch_error, f.consecutive_failures, f.last_http_status, f.next_fetch_at, f.etag, f.last_modified, f.fetch_interval_seconds, f.site_link, f.description, f.language, f.redirect_url, f.redirect_count, f.status, f.failing_since, f.merged_into FROM feeds as f
+JOIN feed_follows as ff ON ff.feed_id = f.id
+WHERE ff.user_id=$1
+`
+
+func (q *Queries) GetFeedsFollowedByUser(ctx context.Context, userID string) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsFollowedByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.LastFetchError,
+			&i.ConsecutiveFailures,
+			&i.LastHttpStatus,
+			&i.NextFetchAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.FetchIntervalSeconds,
+			&i.SiteLink,
+			&i.Description,
+			&i.Language,
+			&i.RedirectUrl,
+			&i.RedirectCount,
+			&i.Status,
+			&i.FailingSince,
+			&i.MergedInto,
 		); err != nil {
 			return nil, err
 		}
@@ -119,13 +336,20 @@ func (q *Queries) GetFeedsByUserId(ctx context.Context, userID string) ([]Feed,
 }
 
 const getNextFeedsToFetch = `-- name: GetNextFeedsToFetch :many
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
-ORDER BY last_fetched_at NULLS FIRST
-LIMIT $1
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, last_fetch_error, consecutive_failures, last_http_status, next_fetch_at, etag, last_modified, fetch_interval_seconds, site_link, description, language, redirect_url, redirect_count, status, failing_since, merged_into FROM feeds
+WHERE status IN ('active', 'erroring')
+AND (next_fetch_at IS NULL OR next_fetch_at <= $1::timestamp)
+ORDER BY next_fetch_at NULLS FIRST
+LIMIT $2
 `
 
-func (q *Queries) GetNextFeedsToFetch(ctx context.Context, limit int32) ([]Feed, error) {
-	rows, err := q.db.QueryContext(ctx, getNextFeedsToFetch, limit)
+type GetNextFeedsToFetchParams struct {
+	Now   time.Time
+	Limit int32
+}
+
+func (q *Queries) GetNextFeedsToFetch(ctx context.Context, arg GetNextFeedsToFetchParams) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getNextFeedsToFetch, arg.Now, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -141,6 +365,21 @@ func (q *Queries) GetNextFeedsToFetch(ctx context.Context, limit int32) ([]Feed,
 			&i.Url,
 			&i.UserID,
 			&i.LastFetchedAt,
+			&i.LastFetchError,
+			&i.ConsecutiveFailures,
+			&i.LastHttpStatus,
+			&i.NextFetchAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.FetchIntervalSeconds,
+			&i.SiteLink,
+			&i.Description,
+			&i.Language,
+			&i.RedirectUrl,
+			&i.RedirectCount,
+			&i.Status,
+			&i.FailingSince,
+			&i.MergedInto,
 		); err != nil {
 			return nil, err
 		}
@@ -155,14 +394,266 @@ func (q *Queries) GetNextFeedsToFetch(ctx context.Context, limit int32) ([]Feed,
 	return items, nil
 }
 
+const markFeedFetchFailed = `-- name: MarkFeedFetchFailed :one
+UPDATE feeds SET last_fetched_at=NOW(), updated_at=NOW(), last_fetch_error=$2,
+consecutive_failures=consecutive_failures + 1, last_http_status=$3, next_fetch_at=$4,
+failing_since=COALESCE(failing_since, NOW()),
+status=CASE WHEN status = 'active' THEN 'erroring' ELSE status END
+WHERE id=$1
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, last_fetch_error, consecutive_failures, last_http_status, next_fetch_at, etag, last_modified, fetch_interval_seconds, site_link, description, language, redirect_url, redirect_count, status, failing_since, merged_into
+`
+
+type MarkFeedFetchFailedParams struct {
+	ID             string
+	LastFetchError sql.NullString
+	LastHttpStatus sql.NullInt32
+	NextFetchAt    sql.NullTime
+}
+
+func (q *Queries) MarkFeedFetchFailed(ctx context.Context, arg MarkFeedFetchFailedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, markFeedFetchFailed,
+		arg.ID,
+		arg.LastFetchError,
+		arg.LastHttpStatus,
+		arg.NextFetchAt,
+	)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.LastFetchError,
+		&i.ConsecutiveFailures,
+		&i.LastHttpStatus,
+		&i.NextFetchAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FetchIntervalSeconds,
+		&i.SiteLink,
+		&i.Description,
+		&i.Language,
+		&i.RedirectUrl,
+		&i.RedirectCount,
+		&i.Status,
+		&i.FailingSince,
+		&i.MergedInto,
+	)
+	return i, err
+}
+
 const markFeedFetched = `-- name: MarkFeedFetched :one
-UPDATE feeds SET last_fetched_at=datetime(), updated_at=datetime()
+UPDATE feeds SET last_fetched_at=NOW(), updated_at=NOW(), last_fetch_error=NULL,
+consecutive_failures=0, last_http_status=$2, next_fetch_at=$3, etag=$4, last_modified=$5,
+fetch_interval_seconds=$6, failing_since=NULL,
+status=CASE WHEN status = 'erroring' THEN 'active' ELSE status END
+WHERE id=$1
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, last_fetch_error, consecutive_failures, last_http_status, next_fetch_at, etag, last_modified, fetch_interval_seconds, site_link, description, language, redirect_url, redirect_count, status, failing_since, merged_into
+`
+
+type MarkFeedFetchedParams struct {
+	ID                   string
+	LastHttpStatus       sql.NullInt32
+	NextFetchAt          sql.NullTime
+	Etag                 sql.NullString
+	LastModified         sql.NullString
+	FetchIntervalSeconds sql.NullInt32
+}
+
+func (q *Queries) MarkFeedFetched(ctx context.Context, arg MarkFeedFetchedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, markFeedFetched,
+		arg.ID,
+		arg.LastHttpStatus,
+		arg.NextFetchAt,
+		arg.Etag,
+		arg.LastModified,
+		arg.FetchIntervalSeconds,
+	)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.LastFetchError,
+		&i.ConsecutiveFailures,
+		&i.LastHttpStatus,
+		&i.NextFetchAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FetchIntervalSeconds,
+		&i.SiteLink,
+		&i.Description,
+		&i.Language,
+		&i.RedirectUrl,
+		&i.RedirectCount,
+		&i.Status,
+		&i.FailingSince,
+		&i.MergedInto,
+	)
+	return i, err
+}
+
+const mergeFeed = `-- name: MergeFeed :one
+UPDATE feeds SET status='merged', merged_into=$2, updated_at=NOW(), next_fetch_at=NULL,
+redirect_url=NULL, redirect_count=0
+WHERE id=$1
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, last_fetch_error, consecutive_failures, last_http_status, next_fetch_at, etag, last_modified, fetch_interval_seconds, site_link, description, language, redirect_url, redirect_count, status, failing_since, merged_into
+`
+
+type MergeFeedParams struct {
+	ID         string
+	MergedInto sql.NullString
+}
+
+func (q *Queries) MergeFeed(ctx context.Context, arg MergeFeedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, mergeFeed, arg.ID, arg.MergedInto)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.LastFetchError,
+		&i.ConsecutiveFailures,
+		&i.LastHttpStatus,
+		&i.NextFetchAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FetchIntervalSeconds,
+		&i.SiteLink,
+		&i.Description,
+		&i.Language,
+		&i.RedirectUrl,
+		&i.RedirectCount,
+		&i.Status,
+		&i.FailingSince,
+		&i.MergedInto,
+	)
+	return i, err
+}
+
+const pauseFeed = `-- name: PauseFeed :one
+UPDATE feeds SET status='paused', updated_at=NOW()
+WHERE id=$1 AND user_id=$2 AND status <> 'merged'
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, last_fetch_error, consecutive_failures, last_http_status, next_fetch_at, etag, last_modified, fetch_interval_seconds, site_link, description, language, redirect_url, redirect_count, status, failing_since, merged_into
+`
+
+type PauseFeedParams struct {
+	ID     string
+	UserID string
+}
+
+func (q *Queries) PauseFeed(ctx context.Context, arg PauseFeedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, pauseFeed, arg.ID, arg.UserID)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.LastFetchError,
+		&i.ConsecutiveFailures,
+		&i.LastHttpStatus,
+		&i.NextFetchAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FetchIntervalSeconds,
+		&i.SiteLink,
+		&i.Description,
+		&i.Language,
+		&i.RedirectUrl,
+		&i.RedirectCount,
+		&i.Status,
+		&i.FailingSince,
+		&i.MergedInto,
+	)
+	return i, err
+}
+
+const reactivateFeed = `-- name: ReactivateFeed :one
+UPDATE feeds SET status='active', updated_at=NOW(), failing_since=NULL,
+consecutive_failures=0, next_fetch_at=NULL
+WHERE id=$1 AND user_id=$2 AND status <> 'merged'
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, last_fetch_error, consecutive_failures, last_http_status, next_fetch_at, etag, last_modified, fetch_interval_seconds, site_link, description, language, redirect_url, redirect_count, status, failing_since, merged_into
+`
+
+type ReactivateFeedParams struct {
+	ID     string
+	UserID string
+}
+
+func (q *Queries) ReactivateFeed(ctx context.Context, arg ReactivateFeedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, reactivateFeed, arg.ID, arg.UserID)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.LastFetchError,
+		&i.ConsecutiveFailures,
+		&i.LastHttpStatus,
+		&i.NextFetchAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FetchIntervalSeconds,
+		&i.SiteLink,
+		&i.Description,
+		&i.Language,
+		&i.RedirectUrl,
+		&i.RedirectCount,
+		&i.Status,
+		&i.FailingSince,
+		&i.MergedInto,
+	)
+	return i, err
+}
+
+const recordFeedRedirect = `-- name: RecordFeedRedirect :exec
+UPDATE feeds SET redirect_url=$2, redirect_count=$3
 WHERE id=$1
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
 `
 
-func (q *Queries) MarkFeedFetched(ctx context.Context, id string) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, markFeedFetched, id)
+type RecordFeedRedirectParams struct {
+	ID            string
+	RedirectUrl   sql.NullString
+	RedirectCount int32
+}
+
+func (q *Queries) RecordFeedRedirect(ctx context.Context, arg RecordFeedRedirectParams) error {
+	_, err := q.db.ExecContext(ctx, recordFeedRedirect, arg.ID, arg.RedirectUrl, arg.RedirectCount)
+	return err
+}
+
+const updateFeedURL = `-- name: UpdateFeedURL :one
+UPDATE feeds SET url=$2, updated_at=NOW(), redirect_url=NULL, redirect_count=0
+WHERE id=$1
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, last_fetch_error, consecutive_failures, last_http_status, next_fetch_at, etag, last_modified, fetch_interval_seconds, site_link, description, language, redirect_url, redirect_count, status, failing_since, merged_into
+`
+
+type UpdateFeedURLParams struct {
+	ID  string
+	Url string
+}
+
+func (q *Queries) UpdateFeedURL(ctx context.Context, arg UpdateFeedURLParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, updateFeedURL, arg.ID, arg.Url)
 	var i Feed
 	err := row.Scan(
 		&i.ID,
@@ -172,6 +663,21 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, id string) (Feed, error)
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.LastFetchError,
+		&i.ConsecutiveFailures,
+		&i.LastHttpStatus,
+		&i.NextFetchAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FetchIntervalSeconds,
+		&i.SiteLink,
+		&i.Description,
+		&i.Language,
+		&i.RedirectUrl,
+		&i.RedirectCount,
+		&i.Status,
+		&i.FailingSince,
+		&i.MergedInto,
 	)
 	return i, err
 }
@@ -10,13 +10,28 @@ import (
 )
 
 type Feed struct {
-	ID            string
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	Url           string
-	UserID        string
-	LastFetchedAt sql.NullTime
+	ID                   string
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	Name                 string
+	Url                  string
+	UserID               string
+	LastFetchedAt        sql.NullTime
+	LastFetchError       sql.NullString
+	ConsecutiveFailures  int32
+	LastHttpStatus       sql.NullInt32
+	NextFetchAt          sql.NullTime
+	Etag                 sql.NullString
+	LastModified         sql.NullString
+	FetchIntervalSeconds sql.NullInt32
+	SiteLink             string
+	Description          string
+	Language             string
+	RedirectUrl          sql.NullString
+	RedirectCount        int32
+	Status               string
+	FailingSince         sql.NullTime
+	MergedInto           sql.NullString
 }
 
 type FeedFollow struct {
@@ -36,6 +51,45 @@ type Post struct {
 	Description string
 	PublishedAt time.Time
 	FeedID      string
+	Guid        string
+	ImageUrl    sql.NullString
+	Content     string
+	Author      string
+	Excerpt     string
+}
+
+type PostAuthor struct {
+	PostID string
+	Name   string
+}
+
+type PostCategory struct {
+	PostID string
+	Name   string
+}
+
+type PostEnclosure struct {
+	ID              string
+	CreatedAt       time.Time
+	PostID          string
+	Url             string
+	Length          sql.NullInt64
+	MimeType        sql.NullString
+	DurationSeconds sql.NullInt32
+	Episode         sql.NullInt32
+	Season          sql.NullInt32
+	ImageUrl        sql.NullString
+}
+
+type PostRevision struct {
+	ID          string
+	CreatedAt   time.Time
+	PostID      string
+	Title       string
+	Url         string
+	Description string
+	RevisedAt   time.Time
+	Content     string
 }
 
 type User struct {
@@ -45,3 +99,17 @@ type User struct {
 	Name      string
 	Apikey    string
 }
+
+type WebsubSubscription struct {
+	ID             string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	FeedID         string
+	HubUrl         string
+	TopicUrl       string
+	Secret         string
+	State          string
+	RequestedAt    time.Time
+	LeaseExpiresAt sql.NullTime
+	LastError      sql.NullString
+}
@@ -7,37 +7,45 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 )
 
-const createPost = `-- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id
+const adoptLegacyPost = `-- name: AdoptLegacyPost :execrows
+WITH inserted AS (
+    DELETE FROM posts as p
+    WHERE p.id = $1
+    AND EXISTS (SELECT 1 FROM posts as l WHERE l.feed_id = p.feed_id AND l.guid = $2)
+    RETURNING p.feed_id, p.guid
+)
+UPDATE posts SET guid = i.guid
+FROM inserted as i
+WHERE posts.feed_id = i.feed_id AND posts.guid = $2
 `
 
-type CreatePostParams struct {
-	ID          string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Url         string
-	Description string
-	PublishedAt time.Time
-	FeedID      string
+type AdoptLegacyPostParams struct {
+	ID  string
+	Url string
 }
 
-func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
-	row := q.db.QueryRowContext(ctx, createPost,
-		arg.ID,
-		arg.CreatedAt,
-		arg.UpdatedAt,
-		arg.Title,
-		arg.Url,
-		arg.Description,
-		arg.PublishedAt,
-		arg.FeedID,
-	)
+// AdoptLegacyPost runs after a post was inserted under its guid. When the feed
+// still has the copy stored under the post's url before guids existed, the
+// new row goes and the old one takes its guid, keeping its id and history.
+func (q *Queries) AdoptLegacyPost(ctx context.Context, arg AdoptLegacyPostParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, adoptLegacyPost, arg.ID, arg.Url)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getPostByID = `-- name: GetPostByID :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, guid, image_url, content, author, excerpt FROM posts
+WHERE id = $1
+`
+
+func (q *Queries) GetPostByID(ctx context.Context, id string) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPostByID, id)
 	var i Post
 	err := row.Scan(
 		&i.ID,
@@ -48,21 +56,77 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.Description,
 		&i.PublishedAt,
 		&i.FeedID,
+		&i.Guid,
+		&i.ImageUrl,
+		&i.Content,
+		&i.Author,
+		&i.Excerpt,
 	)
 	return i, err
 }
 
+const getPostsByFeedId = `-- name: GetPostsByFeedId :many
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, guid, image_url, content, author, excerpt FROM posts
+WHERE feed_id = $1
+ORDER BY guid
+`
+
+func (q *Queries) GetPostsByFeedId(ctx context.Context, feedID string) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsByFeedId, feedID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Guid,
+			&i.ImageUrl,
+			&i.Content,
+			&i.Author,
+			&i.Excerpt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getPostsByUser = `-- name: GetPostsByUser :many
-SELECT p.id, p.created_at, p.updated_at, title, url, description, published_at, p.feed_id, ff.id, ff.created_at, ff.updated_at, user_id, ff.feed_id FROM posts as p 
+SELECT p.id, p.created_at, p.updated_at, title, url, description, published_at, p.feed_id, guid, image_url, content, author, excerpt, ff.id, ff.created_at, ff.updated_at, user_id, ff.feed_id FROM posts as p 
 JOIN feed_follows as ff ON p.feed_id = ff.feed_id 
 WHERE ff.user_id=$1
+AND ($2::text IS NULL OR EXISTS (
+    SELECT 1 FROM post_categories as pc WHERE pc.post_id = p.id AND pc.name = $2
+))
+AND ($3::text IS NULL OR EXISTS (
+    SELECT 1 FROM post_authors as pa WHERE pa.post_id = p.id AND pa.name = $3
+))
 ORDER BY published_at DESC
-LIMIT $2
+LIMIT $4
 `
 
 type GetPostsByUserParams struct {
-	UserID string
-	Limit  int32
+	UserID   string
+	Category sql.NullString
+	Author   sql.NullString
+	Limit    int32
 }
 
 type GetPostsByUserRow struct {
@@ -74,6 +138,11 @@ type GetPostsByUserRow struct {
 	Description string
 	PublishedAt time.Time
 	FeedID      string
+	Guid        string
+	ImageUrl    sql.NullString
+	Content     string
+	Author      string
+	Excerpt     string
 	ID_2        string
 	CreatedAt_2 time.Time
 	UpdatedAt_2 time.Time
@@ -82,7 +151,12 @@ type GetPostsByUserRow struct {
 }
 
 func (q *Queries) GetPostsByUser(ctx context.Context, arg GetPostsByUserParams) ([]GetPostsByUserRow, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsByUser, arg.UserID, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, getPostsByUser,
+		arg.UserID,
+		arg.Category,
+		arg.Author,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -99,6 +173,11 @@ func (q *Queries) GetPostsByUser(ctx context.Context, arg GetPostsByUserParams)
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.Guid,
+			&i.ImageUrl,
+			&i.Content,
+			&i.Author,
+			&i.Excerpt,
 			&i.ID_2,
 			&i.CreatedAt_2,
 			&i.UpdatedAt_2,
@@ -117,3 +196,100 @@ func (q *Queries) GetPostsByUser(ctx context.Context, arg GetPostsByUserParams)
 	}
 	return items, nil
 }
+
+const movePosts = `-- name: MovePosts :exec
+UPDATE posts SET feed_id=$1
+WHERE feed_id=$2
+AND guid NOT IN (SELECT p.guid FROM posts as p WHERE p.feed_id=$1)
+`
+
+type MovePostsParams struct {
+	ToFeedID   string
+	FromFeedID string
+}
+
+func (q *Queries) MovePosts(ctx context.Context, arg MovePostsParams) error {
+	_, err := q.db.ExecContext(ctx, movePosts, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
+
+const upsertPost = `-- name: UpsertPost :one
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, guid, image_url, content, author, excerpt)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET title = EXCLUDED.title, url = EXCLUDED.url, description = EXCLUDED.description, image_url = EXCLUDED.image_url,
+content = EXCLUDED.content, author = EXCLUDED.author, excerpt = EXCLUDED.excerpt,
+updated_at = CASE WHEN posts.title IS DISTINCT FROM EXCLUDED.title
+    OR posts.url IS DISTINCT FROM EXCLUDED.url
+    OR (posts.description IS DISTINCT FROM EXCLUDED.description AND posts.description IS DISTINCT FROM $14)
+    OR (posts.content IS DISTINCT FROM EXCLUDED.content AND posts.content IS DISTINCT FROM $15)
+    OR posts.image_url IS DISTINCT FROM EXCLUDED.image_url
+    OR posts.author IS DISTINCT FROM EXCLUDED.author
+    THEN EXCLUDED.updated_at ELSE posts.updated_at END
+WHERE posts.title IS DISTINCT FROM EXCLUDED.title
+OR posts.url IS DISTINCT FROM EXCLUDED.url
+OR posts.description IS DISTINCT FROM EXCLUDED.description
+OR posts.image_url IS DISTINCT FROM EXCLUDED.image_url
+OR posts.content IS DISTINCT FROM EXCLUDED.content
+OR posts.author IS DISTINCT FROM EXCLUDED.author
+OR posts.excerpt IS DISTINCT FROM EXCLUDED.excerpt
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, guid, image_url, content, author, excerpt
+`
+
+type UpsertPostParams struct {
+	ID             string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Title          string
+	Url            string
+	Description    string
+	PublishedAt    time.Time
+	FeedID         string
+	Guid           string
+	ImageUrl       sql.NullString
+	Content        string
+	Author         string
+	Excerpt        string
+	RawDescription string
+	RawContent     string
+}
+
+// A post whose stored text is the feed's raw HTML was saved before
+// sanitization; storing the sanitized text is not an edit, so it keeps its
+// updated_at.
+func (q *Queries) UpsertPost(ctx context.Context, arg UpsertPostParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, upsertPost,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Title,
+		arg.Url,
+		arg.Description,
+		arg.PublishedAt,
+		arg.FeedID,
+		arg.Guid,
+		arg.ImageUrl,
+		arg.Content,
+		arg.Author,
+		arg.Excerpt,
+		arg.RawDescription,
+		arg.RawContent,
+	)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.Guid,
+		&i.ImageUrl,
+		&i.Content,
+		&i.Author,
+		&i.Excerpt,
+	)
+	return i, err
+}
@@ -0,0 +1,23 @@
+package internal
+
+import (
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	cases := []struct {
+		old, new, want string
+	}{
+		{"same", "same", ""},
+		{"old title", "new title", "-old title\n+new title\n"},
+		{"a\nb\nc", "a\nc", " a\n-b\n c\n"},
+		{"a\nc", "a\nb\nc", " a\n+b\n c\n"},
+		{"", "added", "-\n+added\n"},
+		{"one\ntwo\nthree", "one\n2\nthree\nfour", " one\n-two\n+2\n three\n+four\n"},
+	}
+	for _, c := range cases {
+		if got := DiffLines(c.old, c.new); got != c.want {
+			t.Errorf("DiffLines(%q, %q) = %q, want %q", c.old, c.new, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// LinkElement holds the attributes of an HTML <link> element, keyed by
+// lowercased name.
+type LinkElement map[string]string
+
+// DocumentLinks returns the <link> elements of an HTML document in
+// document order, together with the href of its first <base> element.
+func DocumentLinks(document string) ([]LinkElement, string) {
+	var links []LinkElement
+	var base string
+	tokenizer := html.NewTokenizer(strings.NewReader(document))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return links, base
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch {
+			case token.Data == "link":
+				link := make(LinkElement, len(token.Attr))
+				for _, attr := range token.Attr {
+					if _, ok := link[attr.Key]; !ok {
+						link[attr.Key] = attr.Val
+					}
+				}
+				links = append(links, link)
+			case token.Data == "base" && base == "":
+				for _, attr := range token.Attr {
+					if attr.Key == "href" {
+						base = attr.Val
+						break
+					}
+				}
+			}
+		}
+	}
+}
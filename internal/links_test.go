@@ -0,0 +1,29 @@
+package internal
+
+import (
+	"testing"
+)
+
+func TestDocumentLinks(t *testing.T) {
+	document := `<!DOCTYPE html>
+<html><head>
+  <base href="https://cdn.example.com/">
+  <base href="https://ignored.example.com/">
+  <LINK REL="alternate" type="application/rss+xml" title="Posts &amp; notes" href="/feed.xml">
+  <script>document.write('<link rel="alternate" href="/fake.xml">')</script>
+  <link rel=stylesheet href=style.css />
+</head><body><p>hi</p></body></html>`
+	links, base := DocumentLinks(document)
+	if base != "https://cdn.example.com/" {
+		t.Errorf("base = %q", base)
+	}
+	if len(links) != 2 {
+		t.Fatalf("got %d links, want 2: %v", len(links), links)
+	}
+	if links[0]["rel"] != "alternate" || links[0]["href"] != "/feed.xml" || links[0]["title"] != "Posts & notes" {
+		t.Errorf("first link = %v", links[0])
+	}
+	if links[1]["rel"] != "stylesheet" || links[1]["href"] != "style.css" {
+		t.Errorf("second link = %v", links[1])
+	}
+}
@@ -0,0 +1,277 @@
+package internal
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// allowedElements lists the elements SanitizeHTML keeps and the attributes
+// each of them may carry. Other elements are unwrapped: the tag goes, its
+// content stays.
+var allowedElements = map[string][]string{
+	"a":          {"href", "title"},
+	"abbr":       {"title"},
+	"b":          nil,
+	"blockquote": {"cite"},
+	"br":         nil,
+	"caption":    nil,
+	"cite":       nil,
+	"code":       nil,
+	"dd":         nil,
+	"del":        {"cite", "datetime"},
+	"details":    nil,
+	"div":        nil,
+	"dl":         nil,
+	"dt":         nil,
+	"em":         nil,
+	"figcaption": nil,
+	"figure":     nil,
+	"h1":         nil,
+	"h2":         nil,
+	"h3":         nil,
+	"h4":         nil,
+	"h5":         nil,
+	"h6":         nil,
+	"hr":         nil,
+	"i":          nil,
+	"img":        {"src", "alt", "title", "width", "height"},
+	"ins":        {"cite", "datetime"},
+	"kbd":        nil,
+	"li":         nil,
+	"mark":       nil,
+	"ol":         {"start"},
+	"p":          nil,
+	"pre":        nil,
+	"q":          {"cite"},
+	"s":          nil,
+	"small":      nil,
+	"span":       nil,
+	"strong":     nil,
+	"sub":        nil,
+	"summary":    nil,
+	"sup":        nil,
+	"table":      nil,
+	"tbody":      nil,
+	"td":         {"colspan", "rowspan"},
+	"tfoot":      nil,
+	"th":         {"colspan", "rowspan", "scope"},
+	"thead":      nil,
+	"time":       {"datetime"},
+	"tr":         nil,
+	"u":          nil,
+	"ul":         nil,
+}
+
+// urlAttributes are resolved against the base URL and limited to the
+// listed schemes.
+var urlAttributes = map[string][]string{
+	"href": {"http", "https", "mailto"},
+	"src":  {"http", "https"},
+	"cite": {"http", "https"},
+}
+
+// droppedElements are removed together with everything inside them.
+var droppedElements = map[string]bool{
+	"applet": true, "embed": true, "frame": true, "frameset": true, "head": true, "iframe": true,
+	"math": true, "noembed": true, "noframes": true, "noscript": true, "object": true, "script": true,
+	"select": true, "style": true, "svg": true, "template": true, "textarea": true, "title": true, "xmp": true,
+}
+
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "frame": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+// blockElements separate words when markup is reduced to plain text.
+var blockElements = map[string]bool{
+	"blockquote": true, "br": true, "dd": true, "div": true, "dl": true, "dt": true, "figcaption": true,
+	"figure": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "hr": true,
+	"li": true, "ol": true, "p": true, "pre": true, "table": true, "td": true, "th": true, "tr": true, "ul": true,
+}
+
+var (
+	textEscaper      = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	attributeEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+)
+
+// SanitizeHTML reduces an HTML fragment to an allowlist of elements and
+// attributes. Scripts, styles, frames and embedded objects are removed with
+// their content, links and images are resolved against base and restricted
+// to safe schemes, and tracking pixels are dropped. The result is well
+// formed: every element it opens is closed.
+func SanitizeHTML(fragment string, base *url.URL) string {
+	var out strings.Builder
+	var open []string
+	walkHTML(fragment, func(token html.Token) {
+		switch token.Type {
+		case html.TextToken:
+			out.WriteString(textEscaper.Replace(token.Data))
+		case html.StartTagToken, html.SelfClosingTagToken:
+			allowed, ok := allowedElements[token.Data]
+			if !ok {
+				return
+			}
+			attrs, ok := sanitizeAttributes(token, allowed, base)
+			if !ok {
+				return
+			}
+			out.WriteString("<" + token.Data + attrs + ">")
+			// HTML ignores the trailing slash on non-void elements, so
+			// <p/> still has to be closed.
+			if !voidElements[token.Data] {
+				open = append(open, token.Data)
+			}
+		case html.EndTagToken:
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != token.Data {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					out.WriteString("</" + open[j] + ">")
+				}
+				open = open[:i]
+				break
+			}
+		}
+	})
+	for i := len(open) - 1; i >= 0; i-- {
+		out.WriteString("</" + open[i] + ">")
+	}
+	return out.String()
+}
+
+// sanitizeAttributes renders the allowed attributes of a start tag. It
+// reports false when the element is not worth keeping at all: an image
+// without a usable source or one sized as a tracking pixel.
+func sanitizeAttributes(token html.Token, allowed []string, base *url.URL) (string, bool) {
+	var out strings.Builder
+	seen := make(map[string]bool)
+	hasSource := false
+	for _, attr := range token.Attr {
+		if attr.Namespace != "" || seen[attr.Key] || !contains(allowed, attr.Key) {
+			continue
+		}
+		seen[attr.Key] = true
+		value := attr.Val
+		if schemes, ok := urlAttributes[attr.Key]; ok {
+			value = resolveURL(base, value, schemes)
+			if value == "" {
+				continue
+			}
+		}
+		if token.Data == "img" && (attr.Key == "width" || attr.Key == "height") && isPixel(value) {
+			return "", false
+		}
+		hasSource = hasSource || attr.Key == "src"
+		out.WriteString(" " + attr.Key + `="` + attributeEscaper.Replace(value) + `"`)
+	}
+	if token.Data == "img" && !hasSource {
+		return "", false
+	}
+	return out.String(), true
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveURL makes ref absolute against base and returns "" when the result
+// uses a scheme outside schemes. Without a base, relative references are
+// kept as they are.
+func resolveURL(base *url.URL, ref string, schemes []string) string {
+	refURL, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return ""
+	}
+	if base != nil {
+		refURL = base.ResolveReference(refURL)
+	}
+	if refURL.Scheme == "" && base == nil {
+		return refURL.String()
+	}
+	if !contains(schemes, refURL.Scheme) {
+		return ""
+	}
+	return refURL.String()
+}
+
+// isPixel reports whether an image dimension is at most one pixel.
+func isPixel(dimension string) bool {
+	size, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(dimension), "px"))
+	return err == nil && size <= 1
+}
+
+// PlainText strips the markup from an HTML fragment, decodes its entities
+// and collapses whitespace, leaving the text a reader would see.
+func PlainText(fragment string) string {
+	var out strings.Builder
+	walkHTML(fragment, func(token html.Token) {
+		switch token.Type {
+		case html.TextToken:
+			out.WriteString(token.Data)
+		case html.StartTagToken, html.SelfClosingTagToken, html.EndTagToken:
+			if blockElements[token.Data] {
+				out.WriteString(" ")
+			}
+		}
+	})
+	return strings.Join(strings.Fields(out.String()), " ")
+}
+
+// walkHTML tokenizes fragment and passes every text, start tag and end tag
+// token outside the dropped elements to emit. Tag and attribute names come
+// lowercased, text and attribute values unescaped. Comments and doctypes
+// are skipped.
+func walkHTML(fragment string, emit func(html.Token)) {
+	tokenizer := html.NewTokenizer(strings.NewReader(fragment))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return
+		case html.TextToken, html.EndTagToken:
+			emit(tokenizer.Token())
+		case html.StartTagToken:
+			token := tokenizer.Token()
+			if droppedElements[token.Data] {
+				if !voidElements[token.Data] {
+					skipElement(tokenizer, token.Data)
+				}
+				continue
+			}
+			emit(token)
+		case html.SelfClosingTagToken:
+			if token := tokenizer.Token(); !droppedElements[token.Data] {
+				emit(token)
+			}
+		}
+	}
+}
+
+// skipElement moves the tokenizer past the end tag matching an already read
+// start tag, allowing for nested elements of the same name. The tokenizer
+// reads raw text elements such as <script> as a single text token, so
+// markup inside them cannot unbalance the count.
+func skipElement(tokenizer *html.Tokenizer, name string) {
+	depth := 1
+	for depth > 0 {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			return
+		}
+		tagName, _ := tokenizer.TagName()
+		switch {
+		case tokenType == html.StartTagToken && string(tagName) == name:
+			depth++
+		case tokenType == html.EndTagToken && string(tagName) == name:
+			depth--
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestSanitizeHTML(t *testing.T) {
+	base, _ := url.Parse("https://blog.example.com/2024/07/post.html")
+	cases := []struct {
+		in, want string
+	}{
+		{`<p>Hello <b>world</b></p>`, `<p>Hello <b>world</b></p>`},
+		{`<P CLASS="x" onclick="steal()">Hi</P>`, `<p>Hi</p>`},
+		{`before<script>alert("<p>x</p>")</script>after`, `beforeafter`},
+		{`<SCRIPT type="text/javascript">document.write("</scriptx>")</SCRIPT>ok`, `ok`},
+		{`<style>p { color: red }</style><p>styled</p>`, `<p>styled</p>`},
+		{`<iframe src="https://evil.example"><p>fallback</p></iframe>text`, `text`},
+		{`<object data="x"><object><p>inner</p></object><p>still inside</p></object>after`, `after`},
+		{`<svg><a href="https://x">y</a></svg>kept`, `kept`},
+		{`<a href="/about">About</a>`, `<a href="https://blog.example.com/about">About</a>`},
+		{`<a href="../img/">up</a>`, `<a href="https://blog.example.com/2024/img/">up</a>`},
+		{`<a href="javascript:alert(1)">x</a>`, `<a>x</a>`},
+		{`<a href="&#106;avascript:alert(1)">x</a>`, `<a>x</a>`},
+		{`<a href="mailto:me@example.com" target="_blank">mail</a>`, `<a href="mailto:me@example.com">mail</a>`},
+		{`<img src="pic.png" alt="A &amp; B" onerror="x()">`, `<img src="https://blog.example.com/2024/07/pic.png" alt="A &amp; B">`},
+		{`<img src="https://t.example.com/p.gif" width="1" height="1">`, ``},
+		{`<img src="data:image/png;base64,AAAA">`, ``},
+		{`<img alt="no source">`, ``},
+		{`<font color="red"><center>unwrapped</center></font>`, `unwrapped`},
+		{`<p>unclosed <em>tags`, `<p>unclosed <em>tags</em></p>`},
+		{`<p><em>misnested</p> text</em>`, `<p><em>misnested</em></p> text`},
+		{`</div>stray end`, `stray end`},
+		{`<!-- comment <script>x</script> -->visible`, `visible`},
+		{`1 < 2 &amp;&amp; 3 > 2`, `1 &lt; 2 &amp;&amp; 3 &gt; 2`},
+		{`it&#39;s &quot;quoted&quot;`, `it's "quoted"`},
+		{`<a href="x" title='say "hi"'>q</a>`, `<a href="https://blog.example.com/2024/07/x" title="say &quot;hi&quot;">q</a>`},
+		{`<br/><hr>`, `<br><hr>`},
+		{`<p/>text`, `<p>text</p>`},
+		{`<p>cut off <a href="x"`, `<p>cut off </p>`},
+	}
+	for _, c := range cases {
+		if got := SanitizeHTML(c.in, base); got != c.want {
+			t.Errorf("SanitizeHTML(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestSanitizeHTMLWithoutBase(t *testing.T) {
+	got := SanitizeHTML(`<a href="/about">About</a><a href="vbscript:x">bad</a>`, nil)
+	want := `<a href="/about">About</a><a>bad</a>`
+	if got != want {
+		t.Errorf("SanitizeHTML = %q, want %q", got, want)
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{`<p>Hello <b>world</b></p><p>Second&nbsp;paragraph</p>`, "Hello world Second paragraph"},
+		{"<ul><li>one</li><li>two</li></ul>", "one two"},
+		{"line<br>break", "line break"},
+		{`Fish &amp; chips<script>var a = "<b>";</script>`, "Fish & chips"},
+		{"  plain\n\ttext  ", "plain text"},
+	}
+	for _, c := range cases {
+		if got := PlainText(c.in); got != c.want {
+			t.Errorf("PlainText(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
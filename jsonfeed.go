@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type JSONFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Description string         `json:"description"`
+	Language    string         `json:"language"`
+	Hubs        []JSONFeedHub  `json:"hubs"`
+	Items       []JSONFeedItem `json:"items"`
+}
+
+type JSONFeedHub struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type JSONFeedItem struct {
+	ID            JSONFeedID           `json:"id"`
+	URL           string               `json:"url"`
+	ExternalURL   string               `json:"external_url"`
+	Title         string               `json:"title"`
+	ContentHTML   string               `json:"content_html"`
+	ContentText   string               `json:"content_text"`
+	Summary       string               `json:"summary"`
+	DatePublished string               `json:"date_published"`
+	DateModified  string               `json:"date_modified"`
+	Author        *JSONFeedAuthor      `json:"author"`
+	Authors       []JSONFeedAuthor     `json:"authors"`
+	Image         string               `json:"image"`
+	Attachments   []JSONFeedAttachment `json:"attachments"`
+	Tags          []string             `json:"tags"`
+}
+
+// JSONFeedID is an item id. JSON Feed 1.1 requires ids to be strings but
+// tells readers to coerce numeric ids, which some publishers still emit.
+type JSONFeedID string
+
+func (id *JSONFeedID) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*id = JSONFeedID(text)
+		return nil
+	}
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return fmt.Errorf("item id %s is neither a string nor a number", data)
+	}
+	*id = JSONFeedID(number)
+	return nil
+}
+
+type JSONFeedAttachment struct {
+	URL               string  `json:"url"`
+	MimeType          string  `json:"mime_type"`
+	SizeInBytes       int64   `json:"size_in_bytes"`
+	DurationInSeconds float64 `json:"duration_in_seconds"`
+}
+
+type JSONFeedAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// jsonFeedVersionPrefix starts the version URL of every JSON Feed release.
+const jsonFeedVersionPrefix = "https://jsonfeed.org/version/"
+
+var errNotJSONFeed = errors.New("JSON document is not a JSON Feed: it has no " + jsonFeedVersionPrefix + " version")
+
+// valid tells a JSON Feed from any other JSON object: it names a
+// JSON Feed version, or it is served as application/feed+json and has
+// an items array.
+func (feed *JSONFeed) valid(mediaType string) bool {
+	if strings.HasPrefix(feed.Version, jsonFeedVersionPrefix) {
+		return true
+	}
+	return mediaType == "application/feed+json" && feed.Items != nil
+}
+
+// authorNames returns the item's author names, preferring the 1.1 authors
+// array over the deprecated 1.0 author object.
+func (item *JSONFeedItem) authorNames() []string {
+	authors := item.Authors
+	if len(authors) == 0 && item.Author != nil {
+		authors = []JSONFeedAuthor{*item.Author}
+	}
+	names := make([]string, 0, len(authors))
+	for _, author := range authors {
+		if author.Name != "" {
+			names = append(names, author.Name)
+		}
+	}
+	return names
+}
+
+// toRSS maps a JSON Feed onto the RSS model so it can share the post
+// ingestion path in processFeeds.
+func (feed *JSONFeed) toRSS() RSS {
+	rss := RSS{
+		Channel: Channel{
+			Title:       feed.Title,
+			Link:        feed.HomePageURL,
+			Description: feed.Description,
+			Language:    feed.Language,
+			Items:       make([]Item, len(feed.Items)),
+		},
+	}
+	if feed.FeedURL != "" {
+		rss.Channel.AtomLinks = append(rss.Channel.AtomLinks, AtomLink{Href: feed.FeedURL, Rel: "self"})
+	}
+	for _, hub := range feed.Hubs {
+		if strings.EqualFold(hub.Type, "websub") {
+			rss.Channel.AtomLinks = append(rss.Channel.AtomLinks, AtomLink{Href: hub.URL, Rel: "hub"})
+		}
+	}
+	for i, entry := range feed.Items {
+		item := &rss.Channel.Items[i]
+		item.Title = entry.Title
+		item.GUID = string(entry.ID)
+		item.Authors = entry.authorNames()
+		item.Author = strings.Join(item.Authors, ", ")
+		item.Categories = entry.Tags
+		item.Link = entry.URL
+		if item.Link == "" {
+			item.Link = entry.ExternalURL
+		}
+		if item.Link == "" {
+			item.Link = string(entry.ID)
+		}
+		item.PubDate = entry.DatePublished
+		if item.PubDate == "" {
+			item.PubDate = entry.DateModified
+		}
+		for _, attachment := range entry.Attachments {
+			enclosure := Enclosure{URL: attachment.URL, Type: attachment.MimeType}
+			if attachment.SizeInBytes > 0 {
+				enclosure.Length = strconv.FormatInt(attachment.SizeInBytes, 10)
+			}
+			if attachment.DurationInSeconds > 0 && item.ITunesDuration == "" {
+				item.ITunesDuration = strconv.Itoa(int(attachment.DurationInSeconds))
+			}
+			item.Enclosures = append(item.Enclosures, enclosure)
+		}
+		if entry.Image != "" {
+			item.MediaThumbnails = []MediaThumbnail{{URL: entry.Image}}
+		}
+		if len(entry.Attachments) > 0 {
+			item.ITunesImage.Href = entry.Image
+		}
+		item.Content = entry.ContentHTML
+		if item.Content == "" {
+			item.Content = entry.ContentText
+		}
+		item.Description = entry.Summary
+		if item.Description == "" {
+			item.Description = item.Content
+		}
+	}
+	return rss
+}
@@ -5,12 +5,15 @@ import (
 	"database/sql"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
-	"sync"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,10 +23,23 @@ import (
 	"github.com/rowinf/blog-aggregator/internal/database"
 )
 
+// shutdownTimeout bounds how long a SIGINT or SIGTERM waits for in-flight
+// requests and feed fetches to finish.
+const shutdownTimeout = 15 * time.Second
+
+// feedValidationTimeout bounds the fetch made while creating a feed, which
+// the client waits on.
+const feedValidationTimeout = 10 * time.Second
+
 type authedHandler func(http.ResponseWriter, *http.Request, database.User)
 
 type ApiConfig struct {
 	DB *database.Queries
+	// DBConn backs DB and is used to run queries in a transaction.
+	DBConn *sql.DB
+	// WebsubCallbackURL is the public base URL hubs reach this server at.
+	// WebSub subscriptions are only made when it is set.
+	WebsubCallbackURL string
 }
 
 type UserParams struct {
@@ -35,12 +51,21 @@ type UserParams struct {
 }
 
 type FeedParams struct {
-	Name      string `json:"name"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
-	Id        string `json:"id"`
-	Url       string `json:"url"`
-	UserId    string `json:"user_id"`
+	Name        string `json:"name"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+	Id          string `json:"id"`
+	Url         string `json:"url"`
+	UserId      string `json:"user_id"`
+	SiteLink    string `json:"site_link"`
+	Description string `json:"description"`
+	Language    string `json:"language"`
+	// Status is active, erroring, dead, paused or merged. LastFetchError
+	// explains why a feed is erroring or dead; MergedInto names the feed a
+	// merged feed's followers and posts went to.
+	Status         string `json:"status"`
+	LastFetchError string `json:"last_fetch_error,omitempty"`
+	MergedInto     string `json:"merged_into,omitempty"`
 }
 
 type FeedFollowsParams struct {
@@ -49,6 +74,8 @@ type FeedFollowsParams struct {
 	UpdatedAt string `json:"updated_at"`
 	FeedId    string `json:"feed_id"`
 	UserId    string `json:"user_id"`
+	// FeedStatus lets followers see that a feed has stopped updating.
+	FeedStatus string `json:"feed_status,omitempty"`
 }
 
 type FeedCreationParams struct {
@@ -57,14 +84,20 @@ type FeedCreationParams struct {
 }
 
 type PostParams struct {
-	Id          string `json:"id"`
-	CreatedAt   string `json:"created_at"`
-	UpdatedAt   string `json:"updated_at"`
-	FeedId      string `json:"feed_id"`
-	PublishedAt string `json:"published_at"`
-	Url         string `json:"url"`
-	Description string `json:"description"`
-	Title       string `json:"title"`
+	Id          string            `json:"id"`
+	CreatedAt   string            `json:"created_at"`
+	UpdatedAt   string            `json:"updated_at"`
+	FeedId      string            `json:"feed_id"`
+	PublishedAt string            `json:"published_at"`
+	Url         string            `json:"url"`
+	Description string            `json:"description"`
+	Title       string            `json:"title"`
+	Content     string            `json:"content,omitempty"`
+	ImageUrl    string            `json:"image_url"`
+	Excerpt     string            `json:"excerpt"`
+	Author      string            `json:"author"`
+	Categories  []string          `json:"categories"`
+	Enclosures  []EnclosureParams `json:"enclosures"`
 }
 
 type RSS struct {
@@ -73,28 +106,58 @@ type RSS struct {
 }
 
 type Channel struct {
-	Title         string   `xml:"title"`
-	Link          string   `xml:"link"`
-	Description   string   `xml:"description"`
-	Generator     string   `xml:"generator"`
-	Language      string   `xml:"language"`
-	LastBuildDate string   `xml:"lastBuildDate"`
-	AtomLink      AtomLink `xml:"atom:link"`
-	Items         []Item   `xml:"item"`
+	Title string `xml:"title"`
+	// AtomLinks must precede Link: encoding/xml gives an element to the
+	// first matching field, and Link would also match <atom:link>.
+	AtomLinks       []AtomLink  `xml:"http://www.w3.org/2005/Atom link"`
+	Link            string      `xml:"link"`
+	Description     string      `xml:"description"`
+	Generator       string      `xml:"generator"`
+	Language        string      `xml:"language"`
+	LastBuildDate   string      `xml:"lastBuildDate"`
+	AtomLink        AtomLink    `xml:"atom:link"`
+	TTL             string      `xml:"ttl"`
+	SkipHours       []string    `xml:"skipHours>hour"`
+	SkipDays        []string    `xml:"skipDays>day"`
+	UpdatePeriod    string      `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+	UpdateFrequency string      `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+	ITunesImage     ITunesImage `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+	Items           []Item      `xml:"item"`
 }
 
 type AtomLink struct {
-	Href string `xml:"href,attr"`
-	Rel  string `xml:"rel,attr"`
-	Type string `xml:"type,attr"`
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
 }
 
 type Item struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	PubDate     string `xml:"pubDate"`
-	GUID        string `xml:"guid"`
-	Description string `xml:"description"`
+	// MediaElements must come first; see its doc comment.
+	MediaElements
+	// The iTunes fields must precede Title and Description for the same
+	// reason, or Title would also take <itunes:title>.
+	ITunesTitle   string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd title"`
+	ITunesSummary string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd summary"`
+	Title         string `xml:"title"`
+	// AtomLinks must precede Link, as in Channel.
+	AtomLinks   []AtomLink `xml:"http://www.w3.org/2005/Atom link"`
+	Link        string     `xml:"link"`
+	PubDate     string     `xml:"pubDate"`
+	GUID        string     `xml:"guid"`
+	Description string     `xml:"description"`
+	Content     string     `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Author      string     `xml:"author"`
+	// Authors lists the names behind Author when the source format gives
+	// several; Author then holds them joined for display.
+	Authors        []string    `xml:"-"`
+	Creators       []string    `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Categories     []string    `xml:"category"`
+	Enclosures     []Enclosure `xml:"enclosure"`
+	ITunesDuration string      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	ITunesEpisode  string      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episode"`
+	ITunesSeason   string      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd season"`
+	ITunesImage    ITunesImage `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
 }
 
 func (params *FeedCreationParams) asJSON(feed database.Feed, feedFollow database.FeedFollow) *FeedCreationParams {
@@ -112,6 +175,12 @@ func (params *FeedParams) asJSON(feed database.Feed) *FeedParams {
 	params.Name = feed.Name
 	params.Url = feed.Url
 	params.UserId = feed.UserID
+	params.SiteLink = feed.SiteLink
+	params.Description = feed.Description
+	params.Language = feed.Language
+	params.Status = feed.Status
+	params.LastFetchError = feed.LastFetchError.String
+	params.MergedInto = feed.MergedInto.String
 	return params
 }
 
@@ -133,6 +202,9 @@ func (params *PostParams) asJSON(post database.GetPostsByUserRow) *PostParams {
 	params.Description = post.Description
 	params.Title = post.Title
 	params.PublishedAt = post.PublishedAt.Format(time.RFC3339)
+	params.ImageUrl = post.ImageUrl.String
+	params.Author = post.Author
+	params.Excerpt = post.Excerpt
 	return params
 }
 
@@ -189,13 +261,41 @@ func (cfg *ApiConfig) handleFeedsPost(w http.ResponseWriter, r *http.Request, us
 		internal.RespondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	parsed, err := url.Parse(body.Url)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		internal.RespondWithError(w, http.StatusBadRequest, "url must be an absolute http or https URL")
+		return
+	}
+	if err := fetcher.policy.checkURL(parsed); err != nil {
+		internal.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("url is not allowed: %v", err))
+		return
+	}
+	// Fetch the feed before storing it so broken URLs are rejected up front.
+	// Users often paste a site's homepage; store the feed it advertises.
+	ctx, cancel := context.WithTimeout(r.Context(), feedValidationTimeout)
+	defer cancel()
+	feedURL, rss, err := resolveFeedURL(ctx, body.Url)
+	if err != nil {
+		internal.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("%s is not a usable feed: %v", body.Url, err))
+		return
+	}
+	name := strings.TrimSpace(body.Name)
+	if name == "" {
+		name = strings.TrimSpace(rss.Channel.Title)
+	}
+	if name == "" {
+		name = feedURL
+	}
 	feed, err := cfg.DB.CreateFeed(r.Context(), database.CreateFeedParams{
-		ID:        uuid.NewString(),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		Name:      body.Name,
-		Url:       body.Url,
-		UserID:    user.ID,
+		ID:          uuid.NewString(),
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+		Name:        name,
+		Url:         feedURL,
+		UserID:      user.ID,
+		SiteLink:    strings.TrimSpace(rss.Channel.Link),
+		Description: strings.TrimSpace(rss.Channel.Description),
+		Language:    strings.TrimSpace(rss.Channel.Language),
 	})
 	if err != nil {
 		internal.RespondWithError(w, http.StatusBadRequest, err.Error())
@@ -269,101 +369,192 @@ func (cfg *ApiConfig) handleFeedFollowsGet(w http.ResponseWriter, r *http.Reques
 		internal.RespondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	followed, err := cfg.DB.GetFeedsFollowedByUser(r.Context(), user.ID)
+	if err != nil {
+		internal.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	statuses := make(map[string]string, len(followed))
+	for _, feed := range followed {
+		statuses[feed.ID] = feed.Status
+	}
 	payload := make([]FeedFollowsParams, len(feeds))
 	for index, feed := range feeds {
 		payload[index].asJSON(feed)
+		payload[index].FeedStatus = statuses[feed.FeedID]
 	}
 	internal.RespondWithJSON(w, http.StatusOK, payload)
 }
 
 func (cfg *ApiConfig) handlePostsByUserGet(w http.ResponseWriter, r *http.Request, user database.User) {
+	// ?content=full adds the full article body next to the summary in
+	// description; the default, ?content=summary, leaves it out.
+	var fullContent bool
+	switch r.URL.Query().Get("content") {
+	case "", "summary":
+	case "full":
+		fullContent = true
+	default:
+		internal.RespondWithError(w, http.StatusBadRequest, `content must be "summary" or "full"`)
+		return
+	}
+	// ?category= and ?author= narrow the results; categories are matched in
+	// their normalized form and so are authors, each of a post's authors
+	// matching on its own.
+	var category, author sql.NullString
+	if r.URL.Query().Has("category") {
+		category.String = normalizeCategory(r.URL.Query().Get("category"))
+		category.Valid = true
+	}
+	if r.URL.Query().Has("author") {
+		author.String = normalizeAuthor(r.URL.Query().Get("author"))
+		author.Valid = true
+	}
 	posts, err := cfg.DB.GetPostsByUser(r.Context(), database.GetPostsByUserParams{
-		Limit:  100,
-		UserID: user.ID,
+		Limit:    100,
+		UserID:   user.ID,
+		Category: category,
+		Author:   author,
 	})
 	if err != nil {
 		internal.RespondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	payload := make([]PostParams, len(posts))
+	postIDs := make([]string, len(posts))
 	for index, post := range posts {
-		payload[index].asJSON(post)
+		postIDs[index] = post.ID
 	}
-	internal.RespondWithJSON(w, http.StatusOK, payload)
-}
-
-func FetchRSSFeed(url string) RSS {
-	resp, err := http.Get(url)
+	enclosures, err := cfg.DB.GetEnclosuresByPostIDs(r.Context(), postIDs)
 	if err != nil {
-		log.Fatalf("couldnt fetch: %v", err)
+		internal.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Fatalf("Failed to open rsponse body %v", err)
+	enclosuresByPost := make(map[string][]EnclosureParams)
+	for _, enclosure := range enclosures {
+		params := EnclosureParams{}
+		enclosuresByPost[enclosure.PostID] = append(enclosuresByPost[enclosure.PostID], *params.asJSON(enclosure))
 	}
-
-	var rss RSS
-	err = xml.Unmarshal(body, &rss)
+	categories, err := cfg.DB.GetCategoriesByPostIDs(r.Context(), postIDs)
 	if err != nil {
-		log.Fatalf("Faile to unmarshal XML: %v", err)
+		internal.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	categoriesByPost := make(map[string][]string)
+	for _, category := range categories {
+		categoriesByPost[category.PostID] = append(categoriesByPost[category.PostID], category.Name)
 	}
-	return rss
-}
 
-func ParseDate(dateStr string) (time.Time, error) {
-	layouts := []string{"Mon, 02 Jan 2006 15:04:05 MST", "Mon, 02 Jan 2006 15:04:05 -0700"}
-	for _, layout := range layouts {
-		parsedTime, err := time.Parse(layout, dateStr)
-		if err == nil {
-			return parsedTime, err
+	payload := make([]PostParams, len(posts))
+	for index, post := range posts {
+		payload[index].asJSON(post)
+		if fullContent {
+			payload[index].Content = post.Content
+			if payload[index].Content == "" {
+				payload[index].Content = post.Description
+			}
+		}
+		payload[index].Enclosures = enclosuresByPost[post.ID]
+		if payload[index].Enclosures == nil {
+			payload[index].Enclosures = []EnclosureParams{}
+		}
+		payload[index].Categories = categoriesByPost[post.ID]
+		if payload[index].Categories == nil {
+			payload[index].Categories = []string{}
 		}
 	}
-	return time.Time{}, nil
+	internal.RespondWithJSON(w, http.StatusOK, payload)
 }
 
-func (cfg *ApiConfig) processFeeds() {
-	var wg sync.WaitGroup
-	ticker := time.NewTicker(60 * time.Second)
-	defer ticker.Stop()
-	for range ticker.C {
-		feeds, err := cfg.DB.GetNextFeedsToFetch(context.Background(), 10)
-		if err != nil {
-			log.Printf("failed to process feeds %v", err)
-			continue
+// processFeeds runs the feed scheduler until ctx is cancelled and every
+// in-flight fetch has returned.
+func (cfg *ApiConfig) processFeeds(ctx context.Context) {
+	scheduler := newFeedScheduler(schedulerConfigFromEnv(), cfg.dueFeeds, cfg.processFeed)
+	scheduler.run(ctx)
+}
+
+func (cfg *ApiConfig) dueFeeds(ctx context.Context, limit int32) ([]database.Feed, error) {
+	return cfg.DB.GetNextFeedsToFetch(ctx, database.GetNextFeedsToFetchParams{
+		Now:   time.Now(),
+		Limit: limit,
+	})
+}
+
+func (cfg *ApiConfig) processFeed(ctx context.Context, feed database.Feed) {
+	result, err := FetchFeed(ctx, feed.Url, CacheValidators{
+		ETag:         feed.Etag.String,
+		LastModified: feed.LastModified.String,
+	})
+	status := sql.NullInt32{Int32: int32(result.StatusCode), Valid: result.StatusCode != 0}
+	if err != nil && ctx.Err() != nil {
+		// Shutting down; the feed is still due and will be picked up again.
+		return
+	}
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusGone {
+		cfg.deactivateFeed(ctx, feed, err, status)
+		return
+	}
+	if err != nil {
+		failures := feed.ConsecutiveFailures + 1
+		log.Printf("failed to fetch feed %s (%s), attempt %d: %v", feed.ID, feed.Url, failures, err)
+		failed, markErr := cfg.DB.MarkFeedFetchFailed(ctx, database.MarkFeedFetchFailedParams{
+			ID:             feed.ID,
+			LastFetchError: sql.NullString{String: err.Error(), Valid: true},
+			LastHttpStatus: status,
+			NextFetchAt:    sql.NullTime{Time: time.Now().Add(fetchBackoff(failures)), Valid: true},
+		})
+		if markErr != nil {
+			log.Printf("failed to record fetch error for feed %s: %v", feed.ID, markErr)
+			return
 		}
-		for _, feed := range feeds {
-			wg.Add(1)
-			go func(feed database.Feed) {
-				defer wg.Done()
-				rss := FetchRSSFeed(feed.Url)
-				fmt.Printf("%s\n", rss.Channel.Title)
-				cfg.DB.MarkFeedFetched(context.Background(), feed.ID)
-				for _, item := range rss.Channel.Items {
-					publishedDate, err := ParseDate(item.PubDate)
-					if err != nil {
-						fmt.Printf("date error: %v", err.Error())
-					} else {
-						_, err := cfg.DB.CreatePost(context.Background(), database.CreatePostParams{
-							ID:          uuid.NewString(),
-							CreatedAt:   time.Now(),
-							UpdatedAt:   time.Now(),
-							Title:       item.Title,
-							Url:         item.Link,
-							Description: item.Description,
-							PublishedAt: publishedDate,
-							FeedID:      feed.ID,
-						})
-						if err != nil {
-							fmt.Printf("couldnt create post: %v : %v", item, err.Error())
-						}
-					}
-				}
-			}(feed)
+		if feedIsDead(failed, time.Now()) {
+			cfg.deactivateFeed(ctx, failed, err, status)
 		}
-		wg.Wait()
+		return
+	}
+	// A 304 carries no feed to learn from, so keep the interval computed
+	// from the last full response.
+	interval := defaultFetchInterval
+	if feed.FetchIntervalSeconds.Valid {
+		interval = time.Duration(feed.FetchIntervalSeconds.Int32) * time.Second
+	}
+	nextFetchAt := time.Now().Add(interval)
+	if !result.NotModified {
+		interval = feedFetchInterval(result.RSS.Channel)
+		nextFetchAt = nextFetchTime(result.RSS.Channel, time.Now(), interval)
+	}
+	// Feeds whose updates are pushed are only polled as a fallback.
+	if cfg.syncWebsub(ctx, feed, result) && interval < websubPollInterval {
+		nextFetchAt = time.Now().Add(websubPollInterval)
 	}
+	// Ingest before storing the new validators: once they are stored the
+	// server answers 304 and would never resend posts we failed to save.
+	validators := result.Validators
+	if !result.NotModified {
+		if err := cfg.ingestPosts(ctx, feed.ID, result.RSS.Channel); err != nil {
+			if ctx.Err() != nil {
+				// Shutting down; the feed is still due and will be fetched again.
+				return
+			}
+			log.Printf("couldnt ingest all of feed %s, keeping its old cache validators: %v", feed.ID, err)
+			validators = CacheValidators{ETag: feed.Etag.String, LastModified: feed.LastModified.String}
+		}
+	}
+	_, err = cfg.DB.MarkFeedFetched(ctx, database.MarkFeedFetchedParams{
+		ID:                   feed.ID,
+		LastHttpStatus:       status,
+		NextFetchAt:          sql.NullTime{Time: nextFetchAt, Valid: true},
+		Etag:                 sql.NullString{String: validators.ETag, Valid: validators.ETag != ""},
+		LastModified:         sql.NullString{String: validators.LastModified, Valid: validators.LastModified != ""},
+		FetchIntervalSeconds: sql.NullInt32{Int32: int32(interval / time.Second), Valid: true},
+	})
+	if err != nil {
+		log.Printf("failed to mark feed %s fetched: %v", feed.ID, err)
+	}
+	// Last, so marking the feed fetched cannot overwrite what a move or
+	// merge changed.
+	cfg.followPermanentRedirect(ctx, feed, permanentRedirectTarget(result.Redirects))
 }
 
 func main() {
@@ -372,10 +563,19 @@ func main() {
 	if err != nil {
 		panic("database error")
 	}
+	fetcher = newFeedFetcher(fetcherConfigFromEnv())
 	apiConfig := ApiConfig{
-		DB: database.New(db),
+		DB:                database.New(db),
+		DBConn:            db,
+		WebsubCallbackURL: os.Getenv("WEBSUB_CALLBACK_URL"),
 	}
-	go apiConfig.processFeeds()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	schedulerDone := make(chan struct{})
+	go func() {
+		defer close(schedulerDone)
+		apiConfig.processFeeds(ctx)
+	}()
 	r := http.NewServeMux()
 	port := os.Getenv("PORT")
 	r.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
@@ -416,10 +616,14 @@ func main() {
 	})
 	r.HandleFunc("POST /v1/feeds", apiConfig.middlewareAuth(apiConfig.handleFeedsPost))
 	r.HandleFunc("GET /v1/feeds", apiConfig.handleFeedsGet)
+	r.HandleFunc("PUT /v1/feeds/{feedID}/status", apiConfig.middlewareAuth(apiConfig.handleFeedStatusPut))
 	r.HandleFunc("GET /v1/feed_follows", apiConfig.middlewareAuth(apiConfig.handleFeedFollowsGet))
 	r.HandleFunc("POST /v1/feed_follows", apiConfig.middlewareAuth(apiConfig.handleFeedFollowsPost))
 	r.HandleFunc("DELETE /v1/feed_follows/{feedFollowID}", apiConfig.middlewareAuth(apiConfig.handleFeedFollowsDelete))
 	r.HandleFunc("GET /v1/posts", apiConfig.middlewareAuth(apiConfig.handlePostsByUserGet))
+	r.HandleFunc("GET /v1/posts/{postID}/revisions", apiConfig.middlewareAuth(apiConfig.handlePostRevisionsGet))
+	r.HandleFunc("GET /v1/websub/{feedID}", apiConfig.handleWebsubVerify)
+	r.HandleFunc("POST /v1/websub/{feedID}", apiConfig.handleWebsubPush)
 
 	corsMux := addCorsHeaders(r)
 	// Create a new HTTP server with the corsMux as the handler
@@ -429,8 +633,32 @@ func main() {
 	}
 
 	// Start the server
-	log.Printf("Serving files from %s on port: %s\n", ".", port)
-	if err := server.ListenAndServe(); err != nil {
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("Serving files from %s on port: %s\n", ".", port)
+		serverErr <- server.ListenAndServe()
+	}()
+	select {
+	case err := <-serverErr:
 		panic(err)
+	case <-ctx.Done():
+	}
+
+	// Stop accepting requests, let in-flight ones finish and wait for the
+	// scheduler's workers to drain, all within shutdownTimeout.
+	stop()
+	log.Printf("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("http server shutdown: %v", err)
+	}
+	select {
+	case <-schedulerDone:
+	case <-shutdownCtx.Done():
+		log.Printf("feed scheduler did not stop before the shutdown deadline")
+	}
+	if err := db.Close(); err != nil {
+		log.Printf("closing database: %v", err)
 	}
 }
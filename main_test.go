@@ -1,18 +1,17 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
 	"testing"
 	"time"
-)
 
-func TestFetchRSSFeed(t *testing.T) {
-	url := "https://blog.boot.dev/index.xml"
-	response := FetchRSSFeed(url)
-	want := "Boot.dev Blog"
-	if want != response.Channel.Title {
-		t.Fatalf(`UpdateFeed(url) = %q, want match for %#q, nil`, want, url)
-	}
-}
+	"github.com/rowinf/blog-aggregator/internal/database"
+)
 
 func TestParsePubDate(t *testing.T) {
 	have := "Fri, 26 Jul 2024 00:00:00 +0000"
@@ -32,3 +31,39 @@ func TestParsePubDate(t *testing.T) {
 		t.Error("ParseDate should have returned an error for an invalid date string")
 	}
 }
+
+func TestProcessFeedKeepsValidatorsWhenIngestionFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v2"`)
+		w.Write([]byte(rssFixture))
+	}))
+	defer server.Close()
+	feed := database.Feed{ID: "feed-1", Url: server.URL, Etag: sql.NullString{String: `"v1"`, Valid: true}}
+
+	cfg, db := newFakeDB(t)
+	cfg.processFeed(context.Background(), feed)
+	if got := markedETag(t, db); got != `"v2"` {
+		t.Errorf("stored ETag = %v after a full ingest, want the new one", got)
+	}
+
+	cfg, db = newFakeDB(t)
+	db.fails("UpsertPost", errors.New("connection reset"))
+	cfg.processFeed(context.Background(), feed)
+	if got := markedETag(t, db); got != `"v1"` {
+		t.Errorf("stored ETag = %v after a failed ingest, want the old one kept", got)
+	}
+	calls := db.called()
+	if slices.Index(calls, "MarkFeedFetched") < slices.Index(calls, "UpsertPost") {
+		t.Errorf("calls = %v, want the feed marked fetched after ingesting", calls)
+	}
+}
+
+// markedETag returns the ETag processFeed stored with MarkFeedFetched.
+func markedETag(t *testing.T, db *fakeDB) any {
+	t.Helper()
+	call, ok := db.call("MarkFeedFetched")
+	if !ok {
+		t.Fatalf("MarkFeedFetched was not run; calls: %v", db.called())
+	}
+	return call.Args[3]
+}
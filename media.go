@@ -0,0 +1,135 @@
+package main
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MediaElements holds the Media RSS elements that can appear on an RSS
+// item or Atom entry. It is embedded so encoding/xml decodes the elements
+// straight into the item.
+//
+// RSS has no namespace, so its <title> and <description> fields match
+// <media:title> and <media:description> too. encoding/xml hands an element
+// to the first field that matches, so MediaElements must be embedded ahead
+// of those fields for MediaTitle and MediaDescription to take them.
+type MediaElements struct {
+	MediaTitle       string           `xml:"http://search.yahoo.com/mrss/ title"`
+	MediaDescription string           `xml:"http://search.yahoo.com/mrss/ description"`
+	MediaThumbnails  []MediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	MediaContents    []MediaContent   `xml:"http://search.yahoo.com/mrss/ content"`
+	MediaGroups      []MediaGroup     `xml:"http://search.yahoo.com/mrss/ group"`
+}
+
+type MediaThumbnail struct {
+	URL    string `xml:"url,attr"`
+	Width  string `xml:"width,attr"`
+	Height string `xml:"height,attr"`
+}
+
+type MediaContent struct {
+	URL        string           `xml:"url,attr"`
+	Type       string           `xml:"type,attr"`
+	Medium     string           `xml:"medium,attr"`
+	Width      string           `xml:"width,attr"`
+	Thumbnails []MediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+}
+
+type MediaGroup struct {
+	Thumbnails []MediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	Contents   []MediaContent   `xml:"http://search.yahoo.com/mrss/ content"`
+}
+
+var imgSrc = regexp.MustCompile(`(?is)<img\b[^>]*?\bsrc\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))[^>]*>`)
+var imgDimension = regexp.MustCompile(`(?i)\b(?:width|height)\s*=\s*["']?1(?:px)?["'\s/>]`)
+
+// postImageURL picks a representative image for an item, preferring
+// explicit media metadata over artwork and falling back to the first real
+// image embedded in the description.
+func postImageURL(item Item) string {
+	thumbnails := append([]MediaThumbnail{}, item.MediaThumbnails...)
+	contents := append([]MediaContent{}, item.MediaContents...)
+	for _, group := range item.MediaGroups {
+		thumbnails = append(thumbnails, group.Thumbnails...)
+		contents = append(contents, group.Contents...)
+	}
+	for _, content := range contents {
+		thumbnails = append(thumbnails, content.Thumbnails...)
+	}
+
+	candidates := []string{largestThumbnail(thumbnails)}
+	for _, content := range contents {
+		if content.Medium == "image" || strings.HasPrefix(content.Type, "image/") {
+			candidates = append(candidates, content.URL)
+		}
+	}
+	candidates = append(candidates, item.ITunesImage.Href)
+	for _, enclosure := range item.Enclosures {
+		if strings.HasPrefix(enclosure.Type, "image/") {
+			candidates = append(candidates, enclosure.URL)
+		}
+	}
+	candidates = append(candidates, firstImage(item.Description), firstImage(item.Content))
+
+	for _, candidate := range candidates {
+		if resolved := resolveImageURL(item.Link, candidate); resolved != "" {
+			return resolved
+		}
+	}
+	return ""
+}
+
+func largestThumbnail(thumbnails []MediaThumbnail) string {
+	best, bestWidth := "", -1
+	for _, thumbnail := range thumbnails {
+		if thumbnail.URL == "" {
+			continue
+		}
+		width, err := strconv.Atoi(thumbnail.Width)
+		if err != nil {
+			width = 0
+		}
+		if width > bestWidth {
+			best, bestWidth = thumbnail.URL, width
+		}
+	}
+	return best
+}
+
+// firstImage returns the src of the first <img> in an HTML fragment,
+// skipping 1x1 tracking pixels.
+func firstImage(html string) string {
+	for _, match := range imgSrc.FindAllStringSubmatch(html, -1) {
+		if imgDimension.MatchString(match[0]) {
+			continue
+		}
+		for _, src := range match[1:] {
+			if src != "" {
+				return src
+			}
+		}
+	}
+	return ""
+}
+
+// resolveImageURL makes an image reference absolute against the item link
+// and rejects anything that is not http(s), such as data: URIs.
+func resolveImageURL(base, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	if baseURL, err := url.Parse(base); err == nil {
+		refURL = baseURL.ResolveReference(refURL)
+	}
+	if refURL.Scheme != "http" && refURL.Scheme != "https" {
+		return ""
+	}
+	return refURL.String()
+}
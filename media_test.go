@@ -0,0 +1,109 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestPostImageURLYouTubeAtom(t *testing.T) {
+	body := `<feed xmlns="http://www.w3.org/2005/Atom" xmlns:media="http://search.yahoo.com/mrss/">
+  <entry>
+    <id>yt:video:abc</id>
+    <link rel="alternate" href="https://www.youtube.com/watch?v=abc"/>
+    <media:group>
+      <media:content url="https://www.youtube.com/v/abc" type="application/x-shockwave-flash"/>
+      <media:thumbnail url="https://i.ytimg.com/vi/abc/default.jpg" width="120" height="90"/>
+      <media:thumbnail url="https://i.ytimg.com/vi/abc/hqdefault.jpg" width="480" height="360"/>
+    </media:group>
+  </entry>
+</feed>`
+	rss, err := ParseFeed("", []byte(body))
+	if err != nil {
+		t.Fatalf("ParseFeed returned an error: %v", err)
+	}
+	if got := postImageURL(rss.Channel.Items[0]); got != "https://i.ytimg.com/vi/abc/hqdefault.jpg" {
+		t.Errorf("postImageURL = %q, want the largest thumbnail", got)
+	}
+}
+
+func TestPostImageURLMediaContent(t *testing.T) {
+	body := `<rss version="2.0" xmlns:media="http://search.yahoo.com/mrss/"><channel>
+  <item>
+    <link>https://news.example.com/story</link>
+    <media:content url="https://news.example.com/video.mp4" medium="video"/>
+    <media:content url="https://news.example.com/photo.jpg" medium="image"/>
+    <description>&lt;img src="https://news.example.com/inline.jpg"&gt;</description>
+  </item>
+</channel></rss>`
+	rss, err := ParseFeed("", []byte(body))
+	if err != nil {
+		t.Fatalf("ParseFeed returned an error: %v", err)
+	}
+	if got := postImageURL(rss.Channel.Items[0]); got != "https://news.example.com/photo.jpg" {
+		t.Errorf("postImageURL = %q, want the image media:content", got)
+	}
+}
+
+func TestPostImageURLFromDescription(t *testing.T) {
+	item := Item{
+		Link: "https://blog.example.com/posts/hello/",
+		Description: `<p><img src="https://track.example.com/p.gif" width="1" height="1">
+<img alt="diagram" src='../../images/diagram.png' width="640"></p>`,
+	}
+	if got := postImageURL(item); got != "https://blog.example.com/images/diagram.png" {
+		t.Errorf("postImageURL = %q, want the first real image resolved against the link", got)
+	}
+
+	item = Item{Description: `<img src="data:image/png;base64,AAAA">`}
+	if got := postImageURL(item); got != "" {
+		t.Errorf("postImageURL = %q, want data URIs ignored", got)
+	}
+}
+
+func TestParseFeedEntryMediaElements(t *testing.T) {
+	atomBody := `<feed xmlns="http://www.w3.org/2005/Atom" xmlns:media="http://search.yahoo.com/mrss/">
+  <entry>
+    <id>tag:example.com,2024:1</id>
+    <title>Entry title</title>
+    <link rel="alternate" href="https://example.com/1"/>
+    <content type="html">&lt;p&gt;Entry body&lt;/p&gt;</content>
+    <media:title>Photo title</media:title>
+    <media:content url="https://example.com/photo.jpg" medium="image"/>
+  </entry>
+</feed>`
+	rss, err := ParseFeed("", []byte(atomBody))
+	if err != nil {
+		t.Fatalf("ParseFeed returned an error: %v", err)
+	}
+	item := rss.Channel.Items[0]
+	if item.Title != "Entry title" || item.Content != "<p>Entry body</p>" {
+		t.Errorf("Atom title/content = %q/%q, want the entry's own", item.Title, item.Content)
+	}
+	if len(item.MediaContents) != 1 || item.MediaTitle != "Photo title" {
+		t.Errorf("Atom media = %+v, want the media:content and media:title", item.MediaElements)
+	}
+	if got := postImageURL(item); got != "https://example.com/photo.jpg" {
+		t.Errorf("postImageURL = %q, want the image media:content", got)
+	}
+
+	rssBody := `<rss version="2.0" xmlns:media="http://search.yahoo.com/mrss/"><channel>
+  <item>
+    <title>Item title</title>
+    <link>https://example.com/2</link>
+    <description>Item description</description>
+    <media:title>Media title</media:title>
+    <media:description>Media description</media:description>
+    <media:content url="https://example.com/video.mp4" medium="video"/>
+  </item>
+</channel></rss>`
+	rss, err = ParseFeed("", []byte(rssBody))
+	if err != nil {
+		t.Fatalf("ParseFeed returned an error: %v", err)
+	}
+	item = rss.Channel.Items[0]
+	if item.Title != "Item title" || item.Description != "Item description" {
+		t.Errorf("RSS title/description = %q/%q, want the item's own", item.Title, item.Description)
+	}
+	if item.MediaTitle != "Media title" || item.MediaDescription != "Media description" || len(item.MediaContents) != 1 {
+		t.Errorf("RSS media = %+v", item.MediaElements)
+	}
+}
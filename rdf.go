@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+const rdfNamespace = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+
+// RDF is an RSS 1.0 document. Unlike RSS 2.0 the items are siblings of the
+// channel rather than children of it.
+type RDF struct {
+	XMLName xml.Name   `xml:"RDF"`
+	Channel RDFChannel `xml:"channel"`
+	Items   []RDFItem  `xml:"item"`
+}
+
+type RDFChannel struct {
+	Title string `xml:"title"`
+	// AtomLinks must precede Link, as in Channel.
+	AtomLinks       []AtomLink `xml:"http://www.w3.org/2005/Atom link"`
+	Link            string     `xml:"link"`
+	Description     string     `xml:"description"`
+	Date            string     `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Language        string     `xml:"http://purl.org/dc/elements/1.1/ language"`
+	UpdatePeriod    string     `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+	UpdateFrequency string     `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+}
+
+type RDFItem struct {
+	About       string   `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Date        string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Creators    []string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Subjects    []string `xml:"http://purl.org/dc/elements/1.1/ subject"`
+}
+
+// toRSS maps an RSS 1.0 document onto the RSS model so it can share the
+// post ingestion path in processFeeds.
+func (feed *RDF) toRSS() RSS {
+	rss := RSS{
+		Channel: Channel{
+			Title:           feed.Channel.Title,
+			Link:            feed.Channel.Link,
+			Description:     feed.Channel.Description,
+			Language:        feed.Channel.Language,
+			LastBuildDate:   feed.Channel.Date,
+			UpdatePeriod:    feed.Channel.UpdatePeriod,
+			UpdateFrequency: feed.Channel.UpdateFrequency,
+			AtomLinks:       feed.Channel.AtomLinks,
+			Items:           make([]Item, len(feed.Items)),
+		},
+	}
+	for i, entry := range feed.Items {
+		item := &rss.Channel.Items[i]
+		item.Title = entry.Title
+		item.Link = entry.Link
+		item.GUID = entry.About
+		if item.GUID == "" {
+			item.GUID = entry.Link
+		}
+		item.PubDate = entry.Date
+		item.Creators = entry.Creators
+		item.Authors = authorNames(*item)
+		item.Author = strings.Join(item.Authors, ", ")
+		item.Categories = entry.Subjects
+		item.Description = entry.Description
+		item.Content = entry.Content
+	}
+	return rss
+}
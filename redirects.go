@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/rowinf/blog-aggregator/internal/database"
+)
+
+// permanentRedirectThreshold is how many fetches in a row must be
+// permanently redirected to the same URL before the feed's URL is updated,
+// so a misconfigured server cannot move a feed with a single response.
+const permanentRedirectThreshold = 3
+
+// followPermanentRedirect tracks where a feed's permanent redirects lead
+// and moves the feed there once the redirect has been seen consistently.
+// target is "" when the fetch was not permanently redirected.
+func (cfg *ApiConfig) followPermanentRedirect(ctx context.Context, feed database.Feed, target string) {
+	if target == "" || target == feed.Url {
+		if feed.RedirectUrl.Valid {
+			err := cfg.DB.RecordFeedRedirect(ctx, database.RecordFeedRedirectParams{ID: feed.ID})
+			if err != nil {
+				log.Printf("failed to clear redirect of feed %s: %v", feed.ID, err)
+			}
+		}
+		return
+	}
+	count := int32(1)
+	if feed.RedirectUrl.String == target {
+		count = feed.RedirectCount + 1
+	}
+	if count < permanentRedirectThreshold {
+		err := cfg.DB.RecordFeedRedirect(ctx, database.RecordFeedRedirectParams{
+			ID:            feed.ID,
+			RedirectUrl:   sql.NullString{String: target, Valid: true},
+			RedirectCount: count,
+		})
+		if err != nil {
+			log.Printf("failed to record redirect of feed %s: %v", feed.ID, err)
+		}
+		return
+	}
+	if err := cfg.moveFeed(ctx, feed, target); err != nil {
+		log.Printf("failed to move feed %s to %s: %v", feed.ID, target, err)
+		return
+	}
+	log.Printf("feed %s moved permanently from %s to %s", feed.ID, feed.Url, target)
+}
+
+// moveFeed points a feed at a new URL. When another feed already has that
+// URL the two are merged into it: followers and posts it lacks move over,
+// and the old feed stays with its owner, marked merged and no longer
+// fetched. Followers of both feeds and posts the target already has stay
+// behind on the old feed; moveFeed logs them. A target that was itself
+// merged is followed to the feed it went to, and one that is not being
+// fetched, because it is paused or dead, is refused.
+func (cfg *ApiConfig) moveFeed(ctx context.Context, feed database.Feed, target string) error {
+	tx, err := cfg.DBConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	queries := cfg.DB.WithTx(tx)
+
+	existing, err := queries.GetFeedByURL(ctx, target)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		if _, err := queries.UpdateFeedURL(ctx, database.UpdateFeedURLParams{ID: feed.ID, Url: target}); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	default:
+		for existing.Status == feedStatusMerged && existing.MergedInto.Valid {
+			existing, err = queries.GetFeedByID(ctx, existing.MergedInto.String)
+			if err != nil {
+				return fmt.Errorf("couldnt follow merged feed: %w", err)
+			}
+		}
+		if existing.ID == feed.ID {
+			return errors.New("feed would be merged into itself")
+		}
+		if !feedIsFetched(existing.Status) {
+			return fmt.Errorf("feed %s at that URL is %s, not merging into it", existing.ID, existing.Status)
+		}
+		err = queries.MoveFeedFollows(ctx, database.MoveFeedFollowsParams{ToFeedID: existing.ID, FromFeedID: feed.ID})
+		if err != nil {
+			return fmt.Errorf("couldnt move followers: %w", err)
+		}
+		// Whoever is left already follows the target.
+		dropped, err := queries.DeleteFeedFollowsByFeedId(ctx, feed.ID)
+		if err != nil {
+			return fmt.Errorf("couldnt drop followers: %w", err)
+		}
+		err = queries.MovePosts(ctx, database.MovePostsParams{ToFeedID: existing.ID, FromFeedID: feed.ID})
+		if err != nil {
+			return fmt.Errorf("couldnt move posts: %w", err)
+		}
+		duplicates, err := queries.GetPostsByFeedId(ctx, feed.ID)
+		if err != nil {
+			return fmt.Errorf("couldnt list duplicate posts: %w", err)
+		}
+		if err := queries.DeleteWebsubSubscriptionByFeedID(ctx, feed.ID); err != nil {
+			return fmt.Errorf("couldnt drop websub subscription: %w", err)
+		}
+		_, err = queries.MergeFeed(ctx, database.MergeFeedParams{
+			ID:         feed.ID,
+			MergedInto: sql.NullString{String: existing.ID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		for _, follow := range dropped {
+			log.Printf("feed %s merged into %s: user %s already followed both, dropped their follow of %s",
+				feed.ID, existing.ID, follow.UserID, feed.ID)
+		}
+		for _, post := range duplicates {
+			log.Printf("feed %s merged into %s: post %s (guid %s) duplicates one in %s and stays with %s",
+				feed.ID, existing.ID, post.ID, post.Guid, existing.ID, feed.ID)
+		}
+		return nil
+	}
+	return tx.Commit()
+}
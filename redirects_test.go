@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"slices"
+	"testing"
+
+	"github.com/rowinf/blog-aggregator/internal/database"
+)
+
+func TestMoveFeedMergesIntoExistingFeed(t *testing.T) {
+	cfg, db := newFakeDB(t)
+	old := database.Feed{ID: "feed-old", Url: "https://old.example.com/feed", UserID: "owner", Status: feedStatusActive}
+	existing := database.Feed{ID: "feed-new", Url: "https://new.example.com/feed", Status: feedStatusActive}
+	db.returns("GetFeedByURL", existing)
+	db.returns("DeleteFeedFollowsByFeedId", database.FeedFollow{ID: "follow-1", UserID: "both", FeedID: old.ID})
+	db.returns("GetPostsByFeedId", database.Post{ID: "post-1", FeedID: old.ID, Guid: "shared"})
+	merged := old
+	merged.Status = feedStatusMerged
+	db.returns("MergeFeed", merged)
+
+	if err := cfg.moveFeed(context.Background(), old, existing.Url); err != nil {
+		t.Fatalf("moveFeed returned an error: %v", err)
+	}
+
+	want := []string{
+		"BEGIN", "GetFeedByURL", "MoveFeedFollows", "DeleteFeedFollowsByFeedId", "MovePosts",
+		"GetPostsByFeedId", "DeleteWebsubSubscriptionByFeedID", "MergeFeed", "COMMIT",
+	}
+	if calls := db.called(); !slices.Equal(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+	call, _ := db.call("MergeFeed")
+	if args := []driver.Value{old.ID, existing.ID}; !slices.Equal(call.Args, args) {
+		t.Errorf("MergeFeed args = %v, want %v", call.Args, args)
+	}
+}
+
+func TestMoveFeedUpdatesURLWhenFree(t *testing.T) {
+	cfg, db := newFakeDB(t)
+	old := database.Feed{ID: "feed-old", Url: "https://old.example.com/feed"}
+	db.returns("UpdateFeedURL", old)
+
+	if err := cfg.moveFeed(context.Background(), old, "https://new.example.com/feed"); err != nil {
+		t.Fatalf("moveFeed returned an error: %v", err)
+	}
+	want := []string{"BEGIN", "GetFeedByURL", "UpdateFeedURL", "COMMIT"}
+	if calls := db.called(); !slices.Equal(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestMoveFeedFollowsMergedTargets(t *testing.T) {
+	cfg, db := newFakeDB(t)
+	old := database.Feed{ID: "feed-old", Url: "https://old.example.com/feed", Status: feedStatusActive}
+	retired := database.Feed{
+		ID: "feed-retired", Url: "https://new.example.com/feed", Status: feedStatusMerged,
+		MergedInto: sql.NullString{String: "feed-current", Valid: true},
+	}
+	current := database.Feed{ID: "feed-current", Url: "https://newer.example.com/feed", Status: feedStatusErroring}
+	db.returns("GetFeedByURL", retired)
+	db.returns("GetFeedByID", current)
+	db.returns("MergeFeed", old)
+
+	if err := cfg.moveFeed(context.Background(), old, retired.Url); err != nil {
+		t.Fatalf("moveFeed returned an error: %v", err)
+	}
+	call, _ := db.call("MoveFeedFollows")
+	if args := []driver.Value{current.ID, old.ID}; !slices.Equal(call.Args, args) {
+		t.Errorf("MoveFeedFollows args = %v, want followers moved to the feed the target was merged into", call.Args)
+	}
+}
+
+func TestMoveFeedRefusesTargetsNotFetched(t *testing.T) {
+	for _, status := range []string{feedStatusPaused, feedStatusDead, feedStatusMerged} {
+		cfg, db := newFakeDB(t)
+		old := database.Feed{ID: "feed-old", Url: "https://old.example.com/feed", Status: feedStatusActive}
+		db.returns("GetFeedByURL", database.Feed{ID: "feed-new", Url: "https://new.example.com/feed", Status: status})
+
+		if err := cfg.moveFeed(context.Background(), old, "https://new.example.com/feed"); err == nil {
+			t.Errorf("moveFeed into a %s feed returned no error", status)
+		}
+		want := []string{"BEGIN", "GetFeedByURL", "ROLLBACK"}
+		if calls := db.called(); !slices.Equal(calls, want) {
+			t.Errorf("%s target: calls = %v, want %v", status, calls, want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rowinf/blog-aggregator/internal"
+	"github.com/rowinf/blog-aggregator/internal/database"
+)
+
+// PostRevisionParams is one version of a post. Versions are listed oldest
+// first and end with the current one; Diff shows what changed since the
+// previous version.
+type PostRevisionParams struct {
+	Id          string `json:"id"`
+	PostId      string `json:"post_id"`
+	Title       string `json:"title"`
+	Url         string `json:"url"`
+	Description string `json:"description"`
+	Content     string `json:"content"`
+	RevisedAt   string `json:"revised_at"`
+	Current     bool   `json:"current"`
+	Diff        string `json:"diff"`
+}
+
+func (params *PostRevisionParams) asJSON(revision database.PostRevision) *PostRevisionParams {
+	params.Id = revision.ID
+	params.PostId = revision.PostID
+	params.Title = revision.Title
+	params.Url = revision.Url
+	params.Description = revision.Description
+	params.Content = revision.Content
+	params.RevisedAt = revision.RevisedAt.Format(time.RFC3339)
+	return params
+}
+
+func (params *PostRevisionParams) asCurrentJSON(post database.Post) *PostRevisionParams {
+	params.Id = post.ID
+	params.PostId = post.ID
+	params.Title = post.Title
+	params.Url = post.Url
+	params.Description = post.Description
+	params.Content = post.Content
+	params.RevisedAt = post.UpdatedAt.Format(time.RFC3339)
+	params.Current = true
+	return params
+}
+
+// revisionDiff renders a line diff for every field that changed between
+// two versions of a post.
+func revisionDiff(previous, next *PostRevisionParams) string {
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"title", previous.Title, next.Title},
+		{"url", previous.Url, next.Url},
+		{"description", previous.Description, next.Description},
+		{"content", previous.Content, next.Content},
+	}
+	var diff strings.Builder
+	for _, field := range fields {
+		if field.old == field.new {
+			continue
+		}
+		diff.WriteString("--- " + field.name + "\n")
+		diff.WriteString(internal.DiffLines(field.old, field.new))
+	}
+	return diff.String()
+}
+
+func (cfg *ApiConfig) handlePostRevisionsGet(w http.ResponseWriter, r *http.Request, user database.User) {
+	post, err := cfg.DB.GetPostByID(r.Context(), r.PathValue("postID"))
+	if errors.Is(err, sql.ErrNoRows) {
+		internal.RespondWithError(w, http.StatusNotFound, "post not found")
+		return
+	}
+	if err != nil {
+		internal.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	// Only followers of the post's feed may see its history; to anyone
+	// else the post does not exist.
+	_, err = cfg.DB.GetFeedFollow(r.Context(), database.GetFeedFollowParams{UserID: user.ID, FeedID: post.FeedID})
+	if errors.Is(err, sql.ErrNoRows) {
+		internal.RespondWithError(w, http.StatusNotFound, "post not found")
+		return
+	}
+	if err != nil {
+		internal.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	revisions, err := cfg.DB.GetPostRevisions(r.Context(), post.ID)
+	if err != nil {
+		internal.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	payload := make([]PostRevisionParams, len(revisions)+1)
+	for index, revision := range revisions {
+		payload[index].asJSON(revision)
+	}
+	payload[len(revisions)].asCurrentJSON(post)
+	for index := 1; index < len(payload); index++ {
+		payload[index].Diff = revisionDiff(&payload[index-1], &payload[index])
+	}
+	internal.RespondWithJSON(w, http.StatusOK, payload)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rowinf/blog-aggregator/internal/database"
+)
+
+func TestRevisionDiff(t *testing.T) {
+	previous := &PostRevisionParams{Title: "Go 1.22", Url: "https://example.com/go", Description: "first line\nsecond line"}
+	next := &PostRevisionParams{Title: "Go 1.22 released", Url: "https://example.com/go", Description: "first line\nsecond line, edited"}
+
+	want := "--- title\n-Go 1.22\n+Go 1.22 released\n" +
+		"--- description\n first line\n-second line\n+second line, edited\n"
+	if got := revisionDiff(previous, next); got != want {
+		t.Errorf("revisionDiff = %q, want %q", got, want)
+	}
+	if got := revisionDiff(next, next); got != "" {
+		t.Errorf("revisionDiff of identical versions = %q, want empty", got)
+	}
+}
+
+func TestHandlePostRevisionsGetRequiresFollow(t *testing.T) {
+	post := database.Post{ID: "post-1", FeedID: "feed-1", Title: "Go 1.22"}
+	get := func(cfg *ApiConfig) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/posts/post-1/revisions", nil)
+		req.SetPathValue("postID", post.ID)
+		w := httptest.NewRecorder()
+		cfg.handlePostRevisionsGet(w, req, database.User{ID: "user-1"})
+		return w
+	}
+
+	cfg, db := newFakeDB(t)
+	db.returns("GetPostByID", post)
+	if w := get(cfg); w.Code != http.StatusNotFound {
+		t.Errorf("non-follower got %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if _, ok := db.call("GetPostRevisions"); ok {
+		t.Error("revisions were loaded for a user who does not follow the feed")
+	}
+
+	cfg, db = newFakeDB(t)
+	db.returns("GetPostByID", post)
+	db.returns("GetFeedFollow", database.FeedFollow{ID: "follow-1", UserID: "user-1", FeedID: post.FeedID})
+	if w := get(cfg); w.Code != http.StatusOK {
+		t.Errorf("follower got %d, want %d", w.Code, http.StatusOK)
+	}
+	call, _ := db.call("GetFeedFollow")
+	if len(call.Args) != 2 || call.Args[0] != "user-1" || call.Args[1] != post.FeedID {
+		t.Errorf("GetFeedFollow args = %v, want the user and the post's feed", call.Args)
+	}
+}
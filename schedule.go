@@ -0,0 +1,122 @@
+package main
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultFetchInterval is how long a healthy feed waits between fetches
+	// when it gives no hints and has too few dated items to learn from.
+	defaultFetchInterval = 30 * time.Minute
+	minFetchInterval     = 15 * time.Minute
+	maxFetchInterval     = 24 * time.Hour
+	// minFetchBackoff is the delay after the first failure, doubled for
+	// every further consecutive failure up to maxFetchBackoff.
+	minFetchBackoff = 5 * time.Minute
+	maxFetchBackoff = 24 * time.Hour
+	// observedItemWindow is how many of the newest items are used to
+	// estimate a feed's posting frequency.
+	observedItemWindow = 20
+)
+
+// syndicationPeriods maps the RSS syndication module's sy:updatePeriod values.
+var syndicationPeriods = map[string]time.Duration{
+	"hourly":  time.Hour,
+	"daily":   24 * time.Hour,
+	"weekly":  7 * 24 * time.Hour,
+	"monthly": 30 * 24 * time.Hour,
+	"yearly":  365 * 24 * time.Hour,
+}
+
+// fetchBackoff returns how long to wait before retrying a feed that has
+// failed the given number of times in a row.
+func fetchBackoff(failures int32) time.Duration {
+	if failures < 1 {
+		return minFetchBackoff
+	}
+	backoff := minFetchBackoff
+	for i := int32(1); i < failures; i++ {
+		backoff *= 2
+		if backoff >= maxFetchBackoff {
+			return maxFetchBackoff
+		}
+	}
+	return backoff
+}
+
+// feedFetchInterval works out how often a feed should be polled. It starts
+// from half the feed's observed posting interval, never polls more often
+// than the publisher asks through ttl or sy:updatePeriod, and stays within
+// minFetchInterval and maxFetchInterval.
+func feedFetchInterval(channel Channel) time.Duration {
+	interval := defaultFetchInterval
+	if observed, ok := observedPostInterval(channel.Items); ok {
+		interval = observed / 2
+	}
+	if ttl, err := strconv.Atoi(strings.TrimSpace(channel.TTL)); err == nil && ttl > 0 {
+		interval = max(interval, time.Duration(ttl)*time.Minute)
+	}
+	if period, ok := syndicationPeriods[strings.ToLower(strings.TrimSpace(channel.UpdatePeriod))]; ok {
+		frequency, err := strconv.Atoi(strings.TrimSpace(channel.UpdateFrequency))
+		if err != nil || frequency < 1 {
+			frequency = 1
+		}
+		interval = max(interval, period/time.Duration(frequency))
+	}
+	return min(max(interval, minFetchInterval), maxFetchInterval)
+}
+
+// observedPostInterval returns the median gap between the newest items'
+// publication dates.
+func observedPostInterval(items []Item) (time.Duration, bool) {
+	dates := make([]time.Time, 0, len(items))
+	for _, item := range items {
+		if date, err := ParseDate(item.PubDate); err == nil && !date.IsZero() {
+			dates = append(dates, date)
+		}
+	}
+	slices.SortFunc(dates, func(a, b time.Time) int { return b.Compare(a) })
+	if len(dates) > observedItemWindow {
+		dates = dates[:observedItemWindow]
+	}
+	gaps := make([]time.Duration, 0, len(dates))
+	for i := 1; i < len(dates); i++ {
+		if gap := dates[i-1].Sub(dates[i]); gap > 0 {
+			gaps = append(gaps, gap)
+		}
+	}
+	if len(gaps) == 0 {
+		return 0, false
+	}
+	slices.Sort(gaps)
+	return gaps[len(gaps)/2], true
+}
+
+// nextFetchTime schedules the next fetch one interval from now, pushed past
+// any hours or days the channel lists in skipHours and skipDays. Both are
+// expressed in GMT.
+func nextFetchTime(channel Channel, now time.Time, interval time.Duration) time.Time {
+	skipHours := make(map[int]bool)
+	for _, hour := range channel.SkipHours {
+		if h, err := strconv.Atoi(strings.TrimSpace(hour)); err == nil {
+			skipHours[h%24] = true
+		}
+	}
+	skipDays := make(map[string]bool)
+	for _, day := range channel.SkipDays {
+		skipDays[strings.ToLower(strings.TrimSpace(day))] = true
+	}
+	next := now.Add(interval).UTC()
+	// A week of hours covers every combination, so a feed that skips
+	// everything still gets fetched eventually.
+	for i := 0; i < 7*24; i++ {
+		if !skipHours[next.Hour()] && !skipDays[strings.ToLower(next.Weekday().String())] {
+			break
+		}
+		next = next.Truncate(time.Hour).Add(time.Hour)
+	}
+	return next.In(now.Location())
+}
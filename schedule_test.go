@@ -0,0 +1,116 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFetchBackoff(t *testing.T) {
+	cases := []struct {
+		failures int32
+		want     time.Duration
+	}{
+		{0, 5 * time.Minute},
+		{1, 5 * time.Minute},
+		{2, 10 * time.Minute},
+		{3, 20 * time.Minute},
+		{5, 80 * time.Minute},
+		{9, 1280 * time.Minute},
+		{10, 24 * time.Hour},
+		{1000, 24 * time.Hour},
+	}
+	for _, c := range cases {
+		if got := fetchBackoff(c.failures); got != c.want {
+			t.Errorf("fetchBackoff(%d) = %v, want %v", c.failures, got, c.want)
+		}
+	}
+}
+
+func datedItems(start time.Time, gap time.Duration, count int) []Item {
+	items := make([]Item, count)
+	for i := range items {
+		items[i].PubDate = start.Add(-time.Duration(i) * gap).Format(time.RFC1123Z)
+	}
+	return items
+}
+
+func TestFeedFetchInterval(t *testing.T) {
+	now := time.Date(2024, time.July, 26, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name    string
+		channel Channel
+		want    time.Duration
+	}{
+		{"no hints", Channel{}, defaultFetchInterval},
+		{"observed every 6 hours", Channel{Items: datedItems(now, 6*time.Hour, 5)}, 3 * time.Hour},
+		{"observed every minute is clamped", Channel{Items: datedItems(now, time.Minute, 5)}, minFetchInterval},
+		{"observed monthly is clamped", Channel{Items: datedItems(now, 30*24*time.Hour, 5)}, maxFetchInterval},
+		{"ttl raises the interval", Channel{TTL: "120", Items: datedItems(now, 2*time.Hour, 5)}, 2 * time.Hour},
+		{"ttl below observed is ignored", Channel{TTL: "60", Items: datedItems(now, 6*time.Hour, 5)}, 3 * time.Hour},
+		{"updatePeriod with frequency", Channel{UpdatePeriod: "daily", UpdateFrequency: "4"}, 6 * time.Hour},
+		{"updatePeriod defaults frequency", Channel{UpdatePeriod: " Hourly "}, time.Hour},
+		{"garbage hints are ignored", Channel{TTL: "soon", UpdatePeriod: "fortnightly"}, defaultFetchInterval},
+	}
+	for _, c := range cases {
+		if got := feedFetchInterval(c.channel); got != c.want {
+			t.Errorf("%s: feedFetchInterval = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestNextFetchTimeSkips(t *testing.T) {
+	// Friday 22:30 UTC
+	now := time.Date(2024, time.July, 26, 22, 30, 0, 0, time.UTC)
+
+	got := nextFetchTime(Channel{}, now, time.Hour)
+	if want := now.Add(time.Hour); !got.Equal(want) {
+		t.Errorf("no skips: got %v, want %v", got, want)
+	}
+
+	got = nextFetchTime(Channel{SkipHours: []string{"23", "0", "1"}}, now, 30*time.Minute)
+	if want := time.Date(2024, time.July, 27, 2, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("skipHours: got %v, want %v", got, want)
+	}
+
+	got = nextFetchTime(Channel{SkipDays: []string{"Saturday", "Sunday"}}, now, 2*time.Hour)
+	if want := time.Date(2024, time.July, 29, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("skipDays: got %v, want %v", got, want)
+	}
+
+	all := make([]string, 24)
+	for i := range all {
+		all[i] = strconv.Itoa(i)
+	}
+	got = nextFetchTime(Channel{SkipHours: all}, now, time.Hour)
+	if got.Before(now) {
+		t.Errorf("skipping every hour should still schedule a fetch, got %v", got)
+	}
+}
+
+func TestParseFeedSchedulingHints(t *testing.T) {
+	body := `<rss version="2.0" xmlns:sy="http://purl.org/rss/1.0/modules/syndication/">
+  <channel>
+    <title>Hinted</title>
+    <ttl>90</ttl>
+    <skipHours><hour>1</hour><hour>2</hour></skipHours>
+    <skipDays><day>Sunday</day></skipDays>
+    <sy:updatePeriod>daily</sy:updatePeriod>
+    <sy:updateFrequency>2</sy:updateFrequency>
+  </channel>
+</rss>`
+	rss, err := ParseFeed("", []byte(body))
+	if err != nil {
+		t.Fatalf("ParseFeed returned an error: %v", err)
+	}
+	channel := rss.Channel
+	if channel.TTL != "90" || len(channel.SkipHours) != 2 || len(channel.SkipDays) != 1 {
+		t.Errorf("ttl, skipHours, skipDays = %q, %v, %v", channel.TTL, channel.SkipHours, channel.SkipDays)
+	}
+	if channel.UpdatePeriod != "daily" || channel.UpdateFrequency != "2" {
+		t.Errorf("updatePeriod, updateFrequency = %q, %q", channel.UpdatePeriod, channel.UpdateFrequency)
+	}
+	if got := feedFetchInterval(channel); got != 12*time.Hour {
+		t.Errorf("feedFetchInterval = %v, want 12h", got)
+	}
+}
@@ -6,6 +6,18 @@ RETURNING *;
 -- name: GetFeedFollowsByUserId :many
 SELECT * FROM feed_follows WHERE user_id=$1;
 
+-- name: GetFeedFollow :one
+SELECT * FROM feed_follows WHERE user_id=$1 AND feed_id=$2;
+
 -- name: DeleteFeedFollow :one
 DELETE FROM feed_follows WHERE id=$1
 RETURNING *;
+
+-- name: MoveFeedFollows :exec
+UPDATE feed_follows SET feed_id=sqlc.arg(to_feed_id), updated_at=NOW()
+WHERE feed_id=sqlc.arg(from_feed_id)
+AND user_id NOT IN (SELECT ff.user_id FROM feed_follows as ff WHERE ff.feed_id=sqlc.arg(to_feed_id));
+
+-- name: DeleteFeedFollowsByFeedId :many
+DELETE FROM feed_follows WHERE feed_id=$1
+RETURNING *;
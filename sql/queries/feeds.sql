@@ -1,6 +1,6 @@
 -- name: CreateFeed :one
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
-VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, site_link, description, language)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 RETURNING *;
 
 -- name: GetFeedsByUserId :many
@@ -12,10 +12,68 @@ SELECT * FROM feeds;
 
 -- name: GetNextFeedsToFetch :many
 SELECT * FROM feeds
-ORDER BY last_fetched_at NULLS FIRST
-LIMIT $1;
+WHERE status IN ('active', 'erroring')
+AND (next_fetch_at IS NULL OR next_fetch_at <= sqlc.arg(now)::timestamp)
+ORDER BY next_fetch_at NULLS FIRST
+LIMIT sqlc.arg('limit');
 
 -- name: MarkFeedFetched :one
-UPDATE feeds SET last_fetched_at=datetime(), updated_at=datetime()
+UPDATE feeds SET last_fetched_at=NOW(), updated_at=NOW(), last_fetch_error=NULL,
+consecutive_failures=0, last_http_status=$2, next_fetch_at=$3, etag=$4, last_modified=$5,
+fetch_interval_seconds=$6, failing_since=NULL,
+status=CASE WHEN status = 'erroring' THEN 'active' ELSE status END
 WHERE id=$1
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: MarkFeedFetchFailed :one
+UPDATE feeds SET last_fetched_at=NOW(), updated_at=NOW(), last_fetch_error=$2,
+consecutive_failures=consecutive_failures + 1, last_http_status=$3, next_fetch_at=$4,
+failing_since=COALESCE(failing_since, NOW()),
+status=CASE WHEN status = 'active' THEN 'erroring' ELSE status END
+WHERE id=$1
+RETURNING *;
+
+-- name: DeactivateFeed :one
+UPDATE feeds SET status='dead', last_fetched_at=NOW(), updated_at=NOW(), last_fetch_error=$2,
+last_http_status=$3, next_fetch_at=NULL
+WHERE id=$1 AND status IN ('active', 'erroring')
+RETURNING *;
+
+-- name: RecordFeedRedirect :exec
+UPDATE feeds SET redirect_url=$2, redirect_count=$3
+WHERE id=$1;
+
+-- name: GetFeedByID :one
+SELECT * FROM feeds
+WHERE id = $1;
+
+-- name: GetFeedByURL :one
+SELECT * FROM feeds
+WHERE url = $1;
+
+-- name: UpdateFeedURL :one
+UPDATE feeds SET url=$2, updated_at=NOW(), redirect_url=NULL, redirect_count=0
+WHERE id=$1
+RETURNING *;
+
+-- name: MergeFeed :one
+UPDATE feeds SET status='merged', merged_into=$2, updated_at=NOW(), next_fetch_at=NULL,
+redirect_url=NULL, redirect_count=0
+WHERE id=$1
+RETURNING *;
+
+-- name: PauseFeed :one
+UPDATE feeds SET status='paused', updated_at=NOW()
+WHERE id=$1 AND user_id=$2 AND status <> 'merged'
+RETURNING *;
+
+-- name: ReactivateFeed :one
+UPDATE feeds SET status='active', updated_at=NOW(), failing_since=NULL,
+consecutive_failures=0, next_fetch_at=NULL
+WHERE id=$1 AND user_id=$2 AND status <> 'merged'
+RETURNING *;
+
+-- name: GetFeedsFollowedByUser :many
+SELECT f.* FROM feeds as f
+JOIN feed_follows as ff ON ff.feed_id = f.id
+WHERE ff.user_id=$1;
@@ -0,0 +1,10 @@
+-- name: AddPostAuthors :exec
+INSERT INTO post_authors (post_id, name)
+SELECT p.id, unnest(sqlc.arg(names)::text[]) FROM posts as p
+WHERE p.feed_id = sqlc.arg(feed_id) AND p.guid = sqlc.arg(guid)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteStalePostAuthors :exec
+DELETE FROM post_authors
+WHERE post_id = (SELECT p.id FROM posts as p WHERE p.feed_id = sqlc.arg(feed_id) AND p.guid = sqlc.arg(guid))
+AND NOT (name = ANY(sqlc.arg(names)::text[]));
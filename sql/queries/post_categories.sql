@@ -0,0 +1,15 @@
+-- name: AddPostCategories :exec
+INSERT INTO post_categories (post_id, name)
+SELECT p.id, unnest(sqlc.arg(names)::text[]) FROM posts as p
+WHERE p.feed_id = sqlc.arg(feed_id) AND p.guid = sqlc.arg(guid)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteStalePostCategories :exec
+DELETE FROM post_categories
+WHERE post_id = (SELECT p.id FROM posts as p WHERE p.feed_id = sqlc.arg(feed_id) AND p.guid = sqlc.arg(guid))
+AND NOT (name = ANY(sqlc.arg(names)::text[]));
+
+-- name: GetCategoriesByPostIDs :many
+SELECT * FROM post_categories
+WHERE post_id = ANY(sqlc.arg(post_ids)::text[])
+ORDER BY name;
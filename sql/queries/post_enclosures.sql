@@ -0,0 +1,15 @@
+-- name: UpsertPostEnclosure :exec
+INSERT INTO post_enclosures (id, created_at, post_id, url, length, mime_type, duration_seconds, episode, season, image_url)
+SELECT sqlc.arg(id)::text, sqlc.arg(created_at)::timestamp, p.id, sqlc.arg(url)::text,
+sqlc.narg(length)::bigint, sqlc.narg(mime_type)::text, sqlc.narg(duration_seconds)::integer,
+sqlc.narg(episode)::integer, sqlc.narg(season)::integer, sqlc.narg(image_url)::text
+FROM posts as p
+WHERE p.feed_id = sqlc.arg(feed_id) AND p.guid = sqlc.arg(guid)
+ON CONFLICT (post_id, url) DO UPDATE
+SET length = EXCLUDED.length, mime_type = EXCLUDED.mime_type, duration_seconds = EXCLUDED.duration_seconds,
+episode = EXCLUDED.episode, season = EXCLUDED.season, image_url = EXCLUDED.image_url;
+
+-- name: GetEnclosuresByPostIDs :many
+SELECT * FROM post_enclosures
+WHERE post_id = ANY(sqlc.arg(post_ids)::text[])
+ORDER BY created_at;
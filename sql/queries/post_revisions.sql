@@ -0,0 +1,14 @@
+-- name: CreatePostRevision :exec
+-- Posts stored before sanitization hold the feed's raw HTML. Finding that
+-- raw HTML unchanged means only sanitization differs, which is no edit.
+INSERT INTO post_revisions (id, created_at, post_id, title, url, description, revised_at, content)
+SELECT sqlc.arg(id)::text, sqlc.arg(created_at)::timestamp, p.id, p.title, p.url, p.description, p.updated_at, p.content FROM posts as p
+WHERE p.feed_id = sqlc.arg(feed_id) AND p.guid = sqlc.arg(guid)
+AND (p.title IS DISTINCT FROM sqlc.arg(title) OR p.url IS DISTINCT FROM sqlc.arg(url)
+OR (p.description IS DISTINCT FROM sqlc.arg(description) AND p.description IS DISTINCT FROM sqlc.arg(raw_description))
+OR (p.content IS DISTINCT FROM sqlc.arg(content) AND p.content IS DISTINCT FROM sqlc.arg(raw_content)));
+
+-- name: GetPostRevisions :many
+SELECT * FROM post_revisions
+WHERE post_id = $1
+ORDER BY revised_at, created_at;
@@ -1,11 +1,66 @@
--- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+-- name: UpsertPost :one
+-- A post whose stored text is the feed's raw HTML was saved before
+-- sanitization; storing the sanitized text is not an edit, so it keeps its
+-- updated_at.
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, guid, image_url, content, author, excerpt)
+VALUES (sqlc.arg(id), sqlc.arg(created_at), sqlc.arg(updated_at), sqlc.arg(title), sqlc.arg(url), sqlc.arg(description),
+    sqlc.arg(published_at), sqlc.arg(feed_id), sqlc.arg(guid), sqlc.arg(image_url), sqlc.arg(content), sqlc.arg(author), sqlc.arg(excerpt))
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET title = EXCLUDED.title, url = EXCLUDED.url, description = EXCLUDED.description, image_url = EXCLUDED.image_url,
+content = EXCLUDED.content, author = EXCLUDED.author, excerpt = EXCLUDED.excerpt,
+updated_at = CASE WHEN posts.title IS DISTINCT FROM EXCLUDED.title
+    OR posts.url IS DISTINCT FROM EXCLUDED.url
+    OR (posts.description IS DISTINCT FROM EXCLUDED.description AND posts.description IS DISTINCT FROM sqlc.arg(raw_description))
+    OR (posts.content IS DISTINCT FROM EXCLUDED.content AND posts.content IS DISTINCT FROM sqlc.arg(raw_content))
+    OR posts.image_url IS DISTINCT FROM EXCLUDED.image_url
+    OR posts.author IS DISTINCT FROM EXCLUDED.author
+    THEN EXCLUDED.updated_at ELSE posts.updated_at END
+WHERE posts.title IS DISTINCT FROM EXCLUDED.title
+OR posts.url IS DISTINCT FROM EXCLUDED.url
+OR posts.description IS DISTINCT FROM EXCLUDED.description
+OR posts.image_url IS DISTINCT FROM EXCLUDED.image_url
+OR posts.content IS DISTINCT FROM EXCLUDED.content
+OR posts.author IS DISTINCT FROM EXCLUDED.author
+OR posts.excerpt IS DISTINCT FROM EXCLUDED.excerpt
 RETURNING *;
 
 -- name: GetPostsByUser :many
 SELECT * FROM posts as p 
 JOIN feed_follows as ff ON p.feed_id = ff.feed_id 
-WHERE ff.user_id=$1
+WHERE ff.user_id=sqlc.arg(user_id)
+AND (sqlc.narg(category)::text IS NULL OR EXISTS (
+    SELECT 1 FROM post_categories as pc WHERE pc.post_id = p.id AND pc.name = sqlc.narg(category)
+))
+AND (sqlc.narg(author)::text IS NULL OR EXISTS (
+    SELECT 1 FROM post_authors as pa WHERE pa.post_id = p.id AND pa.name = sqlc.narg(author)
+))
 ORDER BY published_at DESC
-LIMIT $2;
+LIMIT sqlc.arg('limit');
+
+-- name: GetPostByID :one
+SELECT * FROM posts
+WHERE id = $1;
+
+-- name: MovePosts :exec
+UPDATE posts SET feed_id=sqlc.arg(to_feed_id)
+WHERE feed_id=sqlc.arg(from_feed_id)
+AND guid NOT IN (SELECT p.guid FROM posts as p WHERE p.feed_id=sqlc.arg(to_feed_id));
+
+-- name: GetPostsByFeedId :many
+SELECT * FROM posts
+WHERE feed_id = $1
+ORDER BY guid;
+
+-- name: AdoptLegacyPost :execrows
+-- AdoptLegacyPost runs after a post was inserted under its guid. When the feed
+-- still has the copy stored under the post's url before guids existed, the
+-- new row goes and the old one takes its guid, keeping its id and history.
+WITH inserted AS (
+    DELETE FROM posts as p
+    WHERE p.id = sqlc.arg(id)
+    AND EXISTS (SELECT 1 FROM posts as l WHERE l.feed_id = p.feed_id AND l.guid = sqlc.arg(url))
+    RETURNING p.feed_id, p.guid
+)
+UPDATE posts SET guid = i.guid
+FROM inserted as i
+WHERE posts.feed_id = i.feed_id AND posts.guid = sqlc.arg(url);
@@ -0,0 +1,23 @@
+-- name: UpsertWebsubSubscription :one
+INSERT INTO websub_subscriptions (id, created_at, updated_at, feed_id, hub_url, topic_url, secret, state, requested_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, 'pending', $8)
+ON CONFLICT (feed_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at, hub_url = EXCLUDED.hub_url, topic_url = EXCLUDED.topic_url,
+secret = EXCLUDED.secret, state = 'pending', requested_at = EXCLUDED.requested_at, last_error = NULL
+RETURNING *;
+
+-- name: GetWebsubSubscriptionByFeedID :one
+SELECT * FROM websub_subscriptions
+WHERE feed_id = $1;
+
+-- name: ActivateWebsubSubscription :one
+UPDATE websub_subscriptions SET state='active', updated_at=$2, lease_expires_at=$3, last_error=NULL
+WHERE feed_id=$1
+RETURNING *;
+
+-- name: MarkWebsubSubscriptionFailed :exec
+UPDATE websub_subscriptions SET state=$2, updated_at=$3, last_error=$4
+WHERE feed_id=$1;
+
+-- name: DeleteWebsubSubscriptionByFeedID :exec
+DELETE FROM websub_subscriptions WHERE feed_id=$1;
@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE post_revisions(
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    post_id TEXT REFERENCES posts (id) ON DELETE CASCADE NOT NULL,
+    title TEXT NOT NULL,
+    url TEXT NOT NULL,
+    description TEXT NOT NULL,
+    revised_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX post_revisions_post_id_idx ON post_revisions (post_id, revised_at);
+
+-- +goose Down
+DROP TABLE post_revisions;
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE post_enclosures(
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    post_id TEXT REFERENCES posts (id) ON DELETE CASCADE NOT NULL,
+    url TEXT NOT NULL,
+    length BIGINT,
+    mime_type TEXT,
+    duration_seconds INTEGER,
+    episode INTEGER,
+    season INTEGER,
+    image_url TEXT,
+    UNIQUE (post_id, url)
+);
+
+-- +goose Down
+DROP TABLE post_enclosures;
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE websub_subscriptions(
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    feed_id TEXT UNIQUE REFERENCES feeds (id) ON DELETE CASCADE NOT NULL,
+    hub_url TEXT NOT NULL,
+    topic_url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    state TEXT NOT NULL DEFAULT 'pending',
+    requested_at TIMESTAMP NOT NULL,
+    lease_expires_at TIMESTAMP,
+    last_error TEXT
+);
+
+-- +goose Down
+DROP TABLE websub_subscriptions;
@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN status TEXT NOT NULL DEFAULT 'active'
+    CHECK (status IN ('active', 'erroring', 'dead', 'paused'));
+ALTER TABLE feeds ADD COLUMN failing_since TIMESTAMP;
+UPDATE feeds SET status = 'dead' WHERE deactivated_at IS NOT NULL;
+UPDATE feeds SET status = 'erroring', failing_since = COALESCE(last_fetched_at, NOW())
+WHERE deactivated_at IS NULL AND consecutive_failures > 0;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN failing_since;
+ALTER TABLE feeds DROP COLUMN status;
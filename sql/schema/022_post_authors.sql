@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE post_authors(
+    post_id TEXT REFERENCES posts (id) ON DELETE CASCADE NOT NULL,
+    name TEXT NOT NULL,
+    PRIMARY KEY (post_id, name)
+);
+
+CREATE INDEX post_authors_name_idx ON post_authors (name);
+
+-- Stored authors are display strings. Several names are joined with ", ",
+-- but single names such as "Doe, Jane" contain commas too, so keep each
+-- string whole, as ingestion does for an RSS author. Ingestion replaces
+-- these with the feed's own list.
+INSERT INTO post_authors (post_id, name)
+SELECT p.id, regexp_replace(lower(trim(p.author)), '\s+', ' ', 'g')
+FROM posts as p
+WHERE trim(p.author) <> '';
+
+-- +goose Down
+DROP TABLE post_authors;
@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN merged_into TEXT REFERENCES feeds (id) ON DELETE SET NULL;
+ALTER TABLE feeds DROP CONSTRAINT feeds_status_check;
+ALTER TABLE feeds ADD CONSTRAINT feeds_status_check
+    CHECK (status IN ('active', 'erroring', 'dead', 'paused', 'merged'));
+
+-- +goose Down
+UPDATE feeds SET status = 'dead' WHERE status = 'merged';
+ALTER TABLE feeds DROP CONSTRAINT feeds_status_check;
+ALTER TABLE feeds ADD CONSTRAINT feeds_status_check
+    CHECK (status IN ('active', 'erroring', 'dead', 'paused'));
+ALTER TABLE feeds DROP COLUMN merged_into;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- status = 'dead' records deactivation; the timestamp only duplicated it.
+ALTER TABLE feeds DROP COLUMN deactivated_at;
+
+-- +goose Down
+ALTER TABLE feeds ADD COLUMN deactivated_at TIMESTAMP;
+UPDATE feeds SET deactivated_at = updated_at WHERE status = 'dead';
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// errBlockedAddress is returned when a fetch would connect to an address
+// inside our own network rather than a public feed server.
+var errBlockedAddress = errors.New("address is not publicly routable")
+
+// blockedPrefixes are ranges a feed fetch may never reach unless they are
+// allowlisted, on top of what netip classifies as loopback, private,
+// link-local, multicast or unspecified. Link-local covers the
+// 169.254.169.254 metadata service and private covers its IPv6 twin.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"),  // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),    // reserved, including broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64, which maps onto IPv4
+	netip.MustParsePrefix("64:ff9b:1::/48"), // local-use NAT64
+	netip.MustParsePrefix("2002::/16"),      // 6to4, which embeds IPv4
+}
+
+// addressPolicy decides which hosts feed fetches may connect to: public
+// addresses, plus whatever the allowlist names.
+type addressPolicy struct {
+	hosts    map[string]bool
+	prefixes []netip.Prefix
+}
+
+// newAddressPolicy builds a policy from allowlist entries, each a host
+// name, an IP address or a CIDR range. Invalid entries are ignored.
+func newAddressPolicy(allowed []string) addressPolicy {
+	policy := addressPolicy{hosts: make(map[string]bool)}
+	for _, entry := range allowed {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			policy.prefixes = append(policy.prefixes, prefix.Masked())
+		} else if addr, err := netip.ParseAddr(entry); err == nil {
+			policy.prefixes = append(policy.prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+		} else {
+			policy.hosts[strings.TrimSuffix(entry, ".")] = true
+		}
+	}
+	return policy
+}
+
+// allowsHost reports whether host is allowlisted by name.
+func (p addressPolicy) allowsHost(host string) bool {
+	return p.hosts[strings.TrimSuffix(strings.ToLower(host), ".")]
+}
+
+// checkAddr rejects addresses inside our network that are not allowlisted.
+func (p addressPolicy) checkAddr(addr netip.Addr) error {
+	addr = addr.Unmap()
+	for _, prefix := range p.prefixes {
+		if prefix.Contains(addr) {
+			return nil
+		}
+	}
+	blocked := addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() ||
+		addr.IsUnspecified()
+	for _, prefix := range blockedPrefixes {
+		blocked = blocked || prefix.Contains(addr)
+	}
+	if blocked {
+		return fmt.Errorf("%w: %s", errBlockedAddress, addr)
+	}
+	return nil
+}
+
+// checkURL rejects URLs that cannot be a public feed: anything but http
+// and https, and hosts that are internal on their face. Host names are
+// checked again once resolved, by control.
+func (p addressPolicy) checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return errors.New("URL has no host")
+	}
+	if p.allowsHost(host) {
+		return nil
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return p.checkAddr(addr)
+	}
+	name := strings.TrimSuffix(strings.ToLower(host), ".")
+	if name == "localhost" || strings.HasSuffix(name, ".localhost") {
+		return fmt.Errorf("%w: %s", errBlockedAddress, host)
+	}
+	return nil
+}
+
+// control is a net.Dialer Control hook. It runs after DNS resolution for
+// every address a connection is attempted to, so a public name resolving
+// to an internal address is caught as well as an internal URL.
+func (p addressPolicy) control(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errBlockedAddress, address)
+	}
+	return p.checkAddr(addrPort.Addr())
+}
+
+// guardedDialer dials through unguarded when the host being dialed is
+// allowlisted by name and through guarded otherwise.
+func guardedDialer(policy addressPolicy, guarded, unguarded *net.Dialer) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		if host, _, err := net.SplitHostPort(address); err == nil && policy.allowsHost(host) {
+			return unguarded.DialContext(ctx, network, address)
+		}
+		return guarded.DialContext(ctx, network, address)
+	}
+}
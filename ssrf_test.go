@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestAddressPolicyCheckURL(t *testing.T) {
+	policy := newAddressPolicy([]string{"10.1.0.0/16", "Feeds.Internal", "192.168.0.7"})
+	tests := []struct {
+		url     string
+		blocked bool
+	}{
+		{"https://example.com/feed.xml", false},
+		{"http://93.184.216.34/rss", false},
+		{"http://[2606:2800:220:1::]/rss", false},
+		{"http://169.254.169.254/latest/meta-data/", true},
+		{"http://localhost:5432", true},
+		{"http://api.localhost/", true},
+		{"http://127.0.0.1/", true},
+		{"http://[::1]/", true},
+		{"http://[::ffff:127.0.0.1]/", true},
+		{"http://10.0.0.1/", true},
+		{"http://172.16.3.4/", true},
+		{"http://192.168.1.1/", true},
+		{"http://100.64.0.1/", true},
+		{"http://0.0.0.0/", true},
+		{"http://[fd00:ec2::254]/", true},
+		{"http://[fe80::1]/", true},
+		// allowlisted
+		{"http://10.1.2.3/feed", false},
+		{"http://feeds.internal/feed", false},
+		{"http://192.168.0.7/feed", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			parsed, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = policy.checkURL(parsed)
+			if blocked := errors.Is(err, errBlockedAddress); blocked != tt.blocked {
+				t.Errorf("checkURL(%s) = %v, want blocked %v", tt.url, err, tt.blocked)
+			}
+		})
+	}
+}
+
+func TestAddressPolicyCheckURLScheme(t *testing.T) {
+	policy := newAddressPolicy(nil)
+	for _, rawURL := range []string{"file:///etc/passwd", "gopher://example.com/", "http:///feed"} {
+		parsed, err := url.Parse(rawURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := policy.checkURL(parsed); err == nil {
+			t.Errorf("checkURL(%s) should fail", rawURL)
+		}
+	}
+}
+
+func TestAddressPolicyControl(t *testing.T) {
+	// control sees resolved addresses, so a public name pointing inside
+	// our network is caught there.
+	policy := newAddressPolicy([]string{"127.0.0.2"})
+	if err := policy.control("tcp4", "127.0.0.1:80", nil); !errors.Is(err, errBlockedAddress) {
+		t.Errorf("control(127.0.0.1) = %v, want blocked", err)
+	}
+	if err := policy.control("tcp6", "[fd00:ec2::254]:80", nil); !errors.Is(err, errBlockedAddress) {
+		t.Errorf("control(fd00:ec2::254) = %v, want blocked", err)
+	}
+	if err := policy.control("tcp4", "127.0.0.2:80", nil); err != nil {
+		t.Errorf("control(127.0.0.2) = %v, want allowlisted", err)
+	}
+	if err := policy.control("tcp4", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("control(93.184.216.34) = %v, want allowed", err)
+	}
+}
+
+func TestFetcherBlocksInternalAddresses(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(rssFixture))
+	}))
+	defer server.Close()
+
+	withFetcher(t, defaultFetcherConfig())
+	if _, err := FetchRSSFeed(context.Background(), server.URL); !errors.Is(err, errBlockedAddress) {
+		t.Errorf("FetchRSSFeed(%s) = %v, want blocked", server.URL, err)
+	}
+	localhostURL := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	if _, err := FetchRSSFeed(context.Background(), localhostURL); !errors.Is(err, errBlockedAddress) {
+		t.Errorf("FetchRSSFeed(%s) = %v, want blocked", localhostURL, err)
+	}
+	if requests != 0 {
+		t.Errorf("server got %d requests, want none", requests)
+	}
+
+	config := defaultFetcherConfig()
+	config.AllowedHosts = []string{"localhost"}
+	withFetcher(t, config)
+	if _, err := FetchRSSFeed(context.Background(), localhostURL); err != nil {
+		t.Errorf("FetchRSSFeed(%s) with localhost allowlisted returned an error: %v", localhostURL, err)
+	}
+}
+
+func TestFetcherBlocksRedirectsToInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+	}))
+	defer server.Close()
+
+	if _, err := FetchRSSFeed(context.Background(), server.URL); !errors.Is(err, errBlockedAddress) {
+		t.Errorf("FetchRSSFeed = %v, want the redirect blocked", err)
+	}
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rowinf/blog-aggregator/internal"
+	"github.com/rowinf/blog-aggregator/internal/database"
+)
+
+const (
+	// websubLease is the lease asked of hubs; they are free to grant a
+	// different one.
+	websubLease = 10 * 24 * time.Hour
+	// websubRenewBefore renews an active lease this long before it runs
+	// out. Renewal piggybacks on polling, so it must exceed the longest
+	// interval between two polls of a feed.
+	websubRenewBefore = 2 * maxFetchInterval
+	// websubRetryAfter is how long a pending, denied or failed
+	// subscription waits before it is requested again.
+	websubRetryAfter = 6 * time.Hour
+	// websubPollInterval is the shortest polling interval of a feed whose
+	// updates are pushed. Polling carries on as a fallback in case pushes
+	// stop arriving.
+	websubPollInterval = 12 * time.Hour
+	// websubVerifyWindow is how long after a subscription request the hub
+	// may verify or deny it. The callback is unauthenticated, so outside
+	// this window verification requests are refused.
+	websubVerifyWindow = time.Hour
+	// maxWebsubPushSize caps the content a hub may push in one request.
+	maxWebsubPushSize = 10 << 20
+)
+
+// websubLinks returns the hub a feed advertises and the topic to subscribe
+// to, which is the feed's rel="self" URL or else the URL it was fetched from.
+func websubLinks(channel Channel, fetchedURL string) (hub, topic string) {
+	for _, link := range channel.AtomLinks {
+		href := strings.TrimSpace(link.Href)
+		switch {
+		case href == "":
+		case hasLinkRel(link.Rel, "hub") && hub == "":
+			hub = href
+		case hasLinkRel(link.Rel, "self") && topic == "":
+			topic = href
+		}
+	}
+	if topic == "" {
+		topic = fetchedURL
+	}
+	return hub, topic
+}
+
+func websubCallbackURL(base, feedID string) string {
+	return strings.TrimRight(base, "/") + "/v1/websub/" + url.PathEscape(feedID)
+}
+
+// websubActive reports whether a subscription currently has pushes coming.
+func websubActive(subscription database.WebsubSubscription, now time.Time) bool {
+	return subscription.State == "active" && subscription.LeaseExpiresAt.Valid && subscription.LeaseExpiresAt.Time.After(now)
+}
+
+// needsWebsubSubscription reports whether a (re)subscription request is due
+// for a feed advertising hub and topic. found is false when the feed has no
+// subscription yet.
+func needsWebsubSubscription(subscription database.WebsubSubscription, found bool, hub, topic string, now time.Time) bool {
+	if !found || subscription.HubUrl != hub || subscription.TopicUrl != topic {
+		return true
+	}
+	if subscription.State == "active" {
+		return !subscription.LeaseExpiresAt.Valid || subscription.LeaseExpiresAt.Time.Before(now.Add(websubRenewBefore))
+	}
+	return now.Sub(subscription.RequestedAt) >= websubRetryAfter
+}
+
+func newWebsubSecret() string {
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	return hex.EncodeToString(secret)
+}
+
+// requestWebsubSubscription asks a hub to push topic to callback, signing
+// the content with secret. The hub confirms asynchronously by calling back.
+func requestWebsubSubscription(ctx context.Context, hub, topic, callback, secret string) error {
+	form := url.Values{
+		"hub.mode":          {"subscribe"},
+		"hub.topic":         {topic},
+		"hub.callback":      {callback},
+		"hub.secret":        {secret},
+		"hub.lease_seconds": {strconv.Itoa(int(websubLease / time.Second))},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hub, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := fetcher.Do(req)
+	if err != nil {
+		return fmt.Errorf("couldnt reach hub: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &HTTPStatusError{StatusCode: resp.StatusCode}
+	}
+	return nil
+}
+
+// syncWebsub subscribes to, or renews, the hub a feed advertises and
+// reports whether its updates are currently being pushed. A 304 result
+// carries no feed, so the stored hub is renewed instead.
+func (cfg *ApiConfig) syncWebsub(ctx context.Context, feed database.Feed, result FetchResult) bool {
+	if cfg.WebsubCallbackURL == "" {
+		return false
+	}
+	subscription, err := cfg.DB.GetWebsubSubscriptionByFeedID(ctx, feed.ID)
+	found := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("couldnt load websub subscription of feed %s: %v", feed.ID, err)
+		return false
+	}
+	hub, topic := subscription.HubUrl, subscription.TopicUrl
+	if !result.NotModified {
+		hub, topic = websubLinks(result.RSS.Channel, feed.Url)
+	}
+	now := time.Now()
+	active := websubActive(subscription, now)
+	if hub == "" {
+		// The feed stopped advertising a hub; let any lease run out.
+		return active
+	}
+	if !needsWebsubSubscription(subscription, found, hub, topic, now) {
+		return active
+	}
+	// Renewals keep the secret so pushes signed before the hub confirms
+	// are still accepted.
+	secret := subscription.Secret
+	if !found || subscription.HubUrl != hub || secret == "" {
+		secret = newWebsubSecret()
+	}
+	_, err = cfg.DB.UpsertWebsubSubscription(ctx, database.UpsertWebsubSubscriptionParams{
+		ID:          uuid.NewString(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		FeedID:      feed.ID,
+		HubUrl:      hub,
+		TopicUrl:    topic,
+		Secret:      secret,
+		RequestedAt: now,
+	})
+	if err != nil {
+		log.Printf("couldnt save websub subscription of feed %s: %v", feed.ID, err)
+		return active
+	}
+	err = requestWebsubSubscription(ctx, hub, topic, websubCallbackURL(cfg.WebsubCallbackURL, feed.ID), secret)
+	if err != nil {
+		log.Printf("websub subscription of feed %s at %s failed: %v", feed.ID, hub, err)
+		err = cfg.DB.MarkWebsubSubscriptionFailed(ctx, database.MarkWebsubSubscriptionFailedParams{
+			FeedID:    feed.ID,
+			State:     "failed",
+			UpdatedAt: time.Now(),
+			LastError: sql.NullString{String: err.Error(), Valid: true},
+		})
+		if err != nil {
+			log.Printf("couldnt record websub failure of feed %s: %v", feed.ID, err)
+		}
+		return false
+	}
+	return active
+}
+
+// awaitingWebsubVerification checks that a hub's answer to a subscription
+// request concerns the request we have outstanding. Anyone can call the
+// callback, so only a pending subscription requested within
+// websubVerifyWindow may be verified or denied; renewals go back to
+// pending when they are requested.
+func awaitingWebsubVerification(query url.Values, subscription database.WebsubSubscription, now time.Time) error {
+	if query.Get("hub.topic") != subscription.TopicUrl {
+		return errors.New("topic does not match the subscription")
+	}
+	if subscription.State != "pending" {
+		return fmt.Errorf("subscription is %s", subscription.State)
+	}
+	if now.Sub(subscription.RequestedAt) > websubVerifyWindow {
+		return errors.New("subscription request has expired")
+	}
+	return nil
+}
+
+// verifyWebsubIntent checks a hub's subscription verification request
+// against the subscription we asked for and returns the challenge to echo
+// along with the lease the hub granted, capped at websubLease.
+func verifyWebsubIntent(query url.Values, subscription database.WebsubSubscription, now time.Time) (string, time.Duration, error) {
+	if err := awaitingWebsubVerification(query, subscription, now); err != nil {
+		return "", 0, err
+	}
+	challenge := query.Get("hub.challenge")
+	if challenge == "" {
+		return "", 0, errors.New("missing hub.challenge")
+	}
+	leaseSeconds, err := strconv.ParseInt(query.Get("hub.lease_seconds"), 10, 64)
+	if err != nil || leaseSeconds <= 0 {
+		return "", 0, errors.New("invalid hub.lease_seconds")
+	}
+	if leaseSeconds > int64(websubLease/time.Second) {
+		return challenge, websubLease, nil
+	}
+	return challenge, time.Duration(leaseSeconds) * time.Second, nil
+}
+
+// validWebsubSignature checks an X-Hub-Signature header, "method=hexdigest",
+// against the HMAC of body keyed with the subscription secret.
+func validWebsubSignature(header, secret string, body []byte) bool {
+	method, signature, ok := strings.Cut(header, "=")
+	if !ok {
+		return false
+	}
+	var newHash func() hash.Hash
+	switch strings.ToLower(method) {
+	case "sha1":
+		newHash = sha1.New
+	case "sha256":
+		newHash = sha256.New
+	case "sha384":
+		newHash = sha512.New384
+	case "sha512":
+		newHash = sha512.New
+	default:
+		return false
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// handleWebsubVerify answers a hub confirming or denying a subscription.
+func (cfg *ApiConfig) handleWebsubVerify(w http.ResponseWriter, r *http.Request) {
+	feedID := r.PathValue("feedID")
+	subscription, err := cfg.DB.GetWebsubSubscriptionByFeedID(r.Context(), feedID)
+	if errors.Is(err, sql.ErrNoRows) {
+		internal.RespondWithError(w, http.StatusNotFound, "subscription not found")
+		return
+	}
+	if err != nil {
+		internal.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	query := r.URL.Query()
+	switch query.Get("hub.mode") {
+	case "subscribe":
+		challenge, lease, err := verifyWebsubIntent(query, subscription, time.Now())
+		if err != nil {
+			internal.RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		_, err = cfg.DB.ActivateWebsubSubscription(r.Context(), database.ActivateWebsubSubscriptionParams{
+			FeedID:         feedID,
+			UpdatedAt:      time.Now(),
+			LeaseExpiresAt: sql.NullTime{Time: time.Now().Add(lease), Valid: true},
+		})
+		if err != nil {
+			internal.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(challenge))
+	case "denied":
+		if err := awaitingWebsubVerification(query, subscription, time.Now()); err != nil {
+			internal.RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		err = cfg.DB.MarkWebsubSubscriptionFailed(r.Context(), database.MarkWebsubSubscriptionFailedParams{
+			FeedID:    feedID,
+			State:     "denied",
+			UpdatedAt: time.Now(),
+			LastError: sql.NullString{String: query.Get("hub.reason"), Valid: query.Get("hub.reason") != ""},
+		})
+		if err != nil {
+			internal.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	default:
+		// We never ask to unsubscribe, so any other intent is not ours.
+		internal.RespondWithError(w, http.StatusNotFound, "unexpected hub.mode")
+	}
+}
+
+// handleWebsubPush ingests content a hub pushes for a subscribed feed.
+// Pushes with a missing or wrong signature are acknowledged, as the spec
+// asks, but ignored. Pushes for a feed that is paused, dead or merged are
+// refused so the hub drops the subscription.
+func (cfg *ApiConfig) handleWebsubPush(w http.ResponseWriter, r *http.Request) {
+	subscription, err := cfg.DB.GetWebsubSubscriptionByFeedID(r.Context(), r.PathValue("feedID"))
+	if errors.Is(err, sql.ErrNoRows) {
+		// 410 tells the hub to drop a subscription we no longer have.
+		internal.RespondWithError(w, http.StatusGone, "subscription not found")
+		return
+	}
+	if err != nil {
+		internal.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	feed, err := cfg.DB.GetFeedByID(r.Context(), subscription.FeedID)
+	if err != nil {
+		internal.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !feedIsFetched(feed.Status) {
+		internal.RespondWithError(w, http.StatusGone, fmt.Sprintf("feed is %s", feed.Status))
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebsubPushSize+1))
+	if err != nil {
+		internal.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(body) > maxWebsubPushSize {
+		internal.RespondWithError(w, http.StatusRequestEntityTooLarge, "pushed content is too large")
+		return
+	}
+	if !validWebsubSignature(r.Header.Get("X-Hub-Signature"), subscription.Secret, body) {
+		log.Printf("ignoring websub push for feed %s with an invalid signature", subscription.FeedID)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	rss, err := ParseFeed(r.Header.Get("Content-Type"), body)
+	if err != nil {
+		internal.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("failed to parse feed: %v", err))
+		return
+	}
+	if err := cfg.ingestPosts(r.Context(), subscription.FeedID, rss.Channel); err != nil {
+		log.Printf("couldnt ingest websub push for feed %s: %v", subscription.FeedID, err)
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
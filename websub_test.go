@@ -0,0 +1,332 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rowinf/blog-aggregator/internal/database"
+)
+
+func TestWebsubLinks(t *testing.T) {
+	rssBody := `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom"><channel>
+  <link>https://example.com/</link>
+  <atom:link rel="self" href="https://example.com/feed.xml"/>
+  <atom:link rel="hub" href="https://hub.example.net/"/>
+</channel></rss>`
+	rss, err := ParseFeed("", []byte(rssBody))
+	if err != nil {
+		t.Fatalf("ParseFeed returned an error: %v", err)
+	}
+	if rss.Channel.Link != "https://example.com/" {
+		t.Errorf("channel link = %q, want it kept apart from atom:link", rss.Channel.Link)
+	}
+	hub, topic := websubLinks(rss.Channel, "http://example.com/feed.xml")
+	if hub != "https://hub.example.net/" || topic != "https://example.com/feed.xml" {
+		t.Errorf("rss hub, topic = %q, %q", hub, topic)
+	}
+
+	atomBody := `<feed xmlns="http://www.w3.org/2005/Atom"><link rel="hub" href="https://hub.example.net/"/><link href="https://example.com/"/></feed>`
+	rss, err = ParseFeed("", []byte(atomBody))
+	if err != nil {
+		t.Fatalf("ParseFeed returned an error: %v", err)
+	}
+	hub, topic = websubLinks(rss.Channel, "https://example.com/atom.xml")
+	if hub != "https://hub.example.net/" || topic != "https://example.com/atom.xml" {
+		t.Errorf("atom hub, topic = %q, %q, want the fetched URL as topic", hub, topic)
+	}
+
+	rdfBody := `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/"
+  xmlns:atom="http://www.w3.org/2005/Atom">
+  <channel rdf:about="https://example.com/feed.rdf">
+    <link>https://example.com/</link>
+    <atom:link rel="hub" href="https://hub.example.net/"/>
+  </channel>
+</rdf:RDF>`
+	rss, err = ParseFeed("", []byte(rdfBody))
+	if err != nil {
+		t.Fatalf("ParseFeed returned an error: %v", err)
+	}
+	if rss.Channel.Link != "https://example.com/" {
+		t.Errorf("rdf channel link = %q, want it kept apart from atom:link", rss.Channel.Link)
+	}
+	hub, topic = websubLinks(rss.Channel, "https://example.com/feed.rdf")
+	if hub != "https://hub.example.net/" || topic != "https://example.com/feed.rdf" {
+		t.Errorf("rdf hub, topic = %q, %q", hub, topic)
+	}
+
+	jsonBody := `{"version": "https://jsonfeed.org/version/1.1", "feed_url": "https://example.com/feed.json",
+  "hubs": [{"type": "rssCloud", "url": "https://cloud.example.net/"}, {"type": "WebSub", "url": "https://hub.example.net/"}]}`
+	rss, err = ParseFeed("", []byte(jsonBody))
+	if err != nil {
+		t.Fatalf("ParseFeed returned an error: %v", err)
+	}
+	hub, topic = websubLinks(rss.Channel, "https://example.com/other.json")
+	if hub != "https://hub.example.net/" || topic != "https://example.com/feed.json" {
+		t.Errorf("json feed hub, topic = %q, %q", hub, topic)
+	}
+
+	if hub, _ := websubLinks(Channel{}, "https://example.com/feed.xml"); hub != "" {
+		t.Errorf("hub = %q for a feed without one", hub)
+	}
+}
+
+func TestNeedsWebsubSubscription(t *testing.T) {
+	now := time.Date(2024, 7, 26, 12, 0, 0, 0, time.UTC)
+	hub, topic := "https://hub.example.net/", "https://example.com/feed.xml"
+	subscription := func(state string, requested time.Time, lease time.Duration) database.WebsubSubscription {
+		return database.WebsubSubscription{
+			HubUrl:         hub,
+			TopicUrl:       topic,
+			State:          state,
+			RequestedAt:    requested,
+			LeaseExpiresAt: sql.NullTime{Time: now.Add(lease), Valid: lease != 0},
+		}
+	}
+	cases := []struct {
+		name         string
+		subscription database.WebsubSubscription
+		found        bool
+		want         bool
+	}{
+		{"no subscription", database.WebsubSubscription{}, false, true},
+		{"long lease", subscription("active", now.Add(-time.Hour), 5*24*time.Hour), true, false},
+		{"lease ending", subscription("active", now.Add(-time.Hour), 24*time.Hour), true, true},
+		{"recently requested", subscription("pending", now.Add(-time.Hour), 0), true, false},
+		{"pending too long", subscription("pending", now.Add(-websubRetryAfter), 0), true, true},
+		{"recently denied", subscription("denied", now.Add(-time.Hour), 0), true, false},
+	}
+	for _, c := range cases {
+		if got := needsWebsubSubscription(c.subscription, c.found, hub, topic, now); got != c.want {
+			t.Errorf("%s: needsWebsubSubscription = %v, want %v", c.name, got, c.want)
+		}
+	}
+	moved := subscription("active", now.Add(-time.Hour), 5*24*time.Hour)
+	if !needsWebsubSubscription(moved, true, "https://other-hub.example.net/", topic, now) {
+		t.Error("a feed that changed hubs should be subscribed again")
+	}
+}
+
+func TestValidWebsubSignature(t *testing.T) {
+	body := []byte("<feed/>")
+	sign := func(secret string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+	if !validWebsubSignature(sign("s3cret"), "s3cret", body) {
+		t.Error("a correct signature was rejected")
+	}
+	for _, header := range []string{"", "sha256", sign("other"), "md5=00", "sha256=zz"} {
+		if validWebsubSignature(header, "s3cret", body) {
+			t.Errorf("signature %q was accepted", header)
+		}
+	}
+}
+
+func TestVerifyWebsubIntent(t *testing.T) {
+	now := time.Date(2024, 8, 1, 12, 0, 0, 0, time.UTC)
+	subscription := database.WebsubSubscription{
+		TopicUrl:    "https://example.com/feed.xml",
+		State:       "pending",
+		RequestedAt: now.Add(-time.Minute),
+	}
+	query := url.Values{
+		"hub.mode":          {"subscribe"},
+		"hub.topic":         {"https://example.com/feed.xml"},
+		"hub.challenge":     {"abc123"},
+		"hub.lease_seconds": {"86400"},
+	}
+	challenge, lease, err := verifyWebsubIntent(query, subscription, now)
+	if err != nil || challenge != "abc123" || lease != 24*time.Hour {
+		t.Errorf("verifyWebsubIntent = %q, %v, %v", challenge, lease, err)
+	}
+
+	wrongTopic := url.Values{"hub.topic": {"https://example.com/other.xml"}, "hub.challenge": {"x"}, "hub.lease_seconds": {"60"}}
+	if _, _, err := verifyWebsubIntent(wrongTopic, subscription, now); err == nil {
+		t.Error("a verification for another topic was accepted")
+	}
+
+	longLease := url.Values{"hub.topic": {subscription.TopicUrl}, "hub.challenge": {"x"}, "hub.lease_seconds": {"315360000000"}}
+	if _, lease, err := verifyWebsubIntent(longLease, subscription, now); err != nil || lease != websubLease {
+		t.Errorf("verifyWebsubIntent with a huge lease = %v, %v, want it capped at %v", lease, err, websubLease)
+	}
+
+	expired := subscription
+	expired.RequestedAt = now.Add(-websubVerifyWindow - time.Minute)
+	if _, _, err := verifyWebsubIntent(query, expired, now); err == nil {
+		t.Error("a verification long after the request was accepted")
+	}
+	for _, state := range []string{"active", "denied", "failed"} {
+		settled := subscription
+		settled.State = state
+		if _, _, err := verifyWebsubIntent(query, settled, now); err == nil {
+			t.Errorf("a verification for a %s subscription was accepted", state)
+		}
+		if err := awaitingWebsubVerification(query, settled, now); err == nil {
+			t.Errorf("a denial for a %s subscription was accepted", state)
+		}
+	}
+}
+
+// TestWebsubFakeHub runs a subscription against a local hub: the hub
+// receives the subscribe request, verifies intent against our callback and
+// pushes signed content, which the callback accepts and parses.
+func TestWebsubFakeHub(t *testing.T) {
+	topic := "https://example.com/feed.xml"
+	subscription := database.WebsubSubscription{TopicUrl: topic, State: "pending", RequestedAt: time.Now()}
+	pushed := make(chan RSS, 1)
+
+	callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			challenge, _, err := verifyWebsubIntent(r.URL.Query(), subscription, time.Now())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			subscription.State = "active"
+			w.Write([]byte(challenge))
+		case http.MethodPost:
+			body, _ := io.ReadAll(r.Body)
+			if !validWebsubSignature(r.Header.Get("X-Hub-Signature"), subscription.Secret, body) {
+				w.WriteHeader(http.StatusAccepted)
+				return
+			}
+			rss, err := ParseFeed(r.Header.Get("Content-Type"), body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			pushed <- rss
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer callback.Close()
+
+	hubErrors := make(chan string, 4)
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("hub.mode") != "subscribe" || r.Form.Get("hub.topic") != topic || r.Form.Get("hub.lease_seconds") == "" {
+			hubErrors <- "unexpected subscribe request " + r.Form.Encode()
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		callbackURL, secret := r.Form.Get("hub.callback"), r.Form.Get("hub.secret")
+		w.WriteHeader(http.StatusAccepted)
+
+		go func() {
+			verify := callbackURL + "?" + url.Values{
+				"hub.mode":          {"subscribe"},
+				"hub.topic":         {topic},
+				"hub.challenge":     {"challenge-42"},
+				"hub.lease_seconds": {"3600"},
+			}.Encode()
+			resp, err := http.Get(verify)
+			if err != nil {
+				hubErrors <- err.Error()
+				return
+			}
+			echoed, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if string(echoed) != "challenge-42" {
+				hubErrors <- "challenge not echoed: " + string(echoed)
+				return
+			}
+
+			// A forged push is ignored, the signed one is ingested.
+			for _, key := range []string{"forged", secret} {
+				mac := hmac.New(sha256.New, []byte(key))
+				mac.Write([]byte(rssFixture))
+				req, _ := http.NewRequest(http.MethodPost, callbackURL, strings.NewReader(rssFixture))
+				req.Header.Set("Content-Type", "application/rss+xml")
+				req.Header.Set("X-Hub-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					hubErrors <- err.Error()
+					return
+				}
+				resp.Body.Close()
+			}
+		}()
+	}))
+	defer hub.Close()
+
+	subscription.Secret = newWebsubSecret()
+	callbackURL := websubCallbackURL(callback.URL, "feed-1")
+	if err := requestWebsubSubscription(context.Background(), hub.URL, topic, callbackURL, subscription.Secret); err != nil {
+		t.Fatalf("requestWebsubSubscription returned an error: %v", err)
+	}
+
+	select {
+	case rss := <-pushed:
+		if rss.Channel.Title != "Boot.dev Blog" {
+			t.Errorf("pushed feed title = %q", rss.Channel.Title)
+		}
+	case msg := <-hubErrors:
+		t.Fatal(msg)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no push arrived")
+	}
+	if subscription.State != "active" {
+		t.Errorf("subscription state = %q, want active after verification", subscription.State)
+	}
+	select {
+	case rss := <-pushed:
+		t.Errorf("a second push was accepted: %+v", rss.Channel)
+	default:
+	}
+}
+
+func TestRequestWebsubSubscriptionRejected(t *testing.T) {
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "topic not allowed", http.StatusForbidden)
+	}))
+	defer hub.Close()
+
+	err := requestWebsubSubscription(context.Background(), hub.URL, "https://example.com/feed.xml", "https://agg.example.com/v1/websub/1", "secret")
+	if err == nil {
+		t.Fatal("requestWebsubSubscription should fail when the hub refuses")
+	}
+}
+
+func TestHandleWebsubPushChecksFeedStatus(t *testing.T) {
+	body := rssFixture
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(body))
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	push := func(cfg *ApiConfig) int {
+		req := httptest.NewRequest(http.MethodPost, "/v1/websub/feed-1", strings.NewReader(body))
+		req.SetPathValue("feedID", "feed-1")
+		req.Header.Set("Content-Type", "application/rss+xml")
+		req.Header.Set("X-Hub-Signature", signature)
+		w := httptest.NewRecorder()
+		cfg.handleWebsubPush(w, req)
+		return w.Code
+	}
+
+	for _, status := range []string{feedStatusPaused, feedStatusDead, feedStatusMerged, feedStatusActive} {
+		cfg, db := newFakeDB(t)
+		db.returns("GetWebsubSubscriptionByFeedID", database.WebsubSubscription{FeedID: "feed-1", Secret: "s3cret", State: "active"})
+		db.returns("GetFeedByID", database.Feed{ID: "feed-1", Status: status})
+
+		code := push(cfg)
+		_, ingested := db.call("UpsertPost")
+		if feedIsFetched(status) {
+			if code != http.StatusAccepted || !ingested {
+				t.Errorf("%s feed: got %d, ingested %v; want the push accepted and ingested", status, code, ingested)
+			}
+		} else if code != http.StatusGone || ingested {
+			t.Errorf("%s feed: got %d, ingested %v; want %d and nothing ingested", status, code, ingested, http.StatusGone)
+		}
+	}
+}
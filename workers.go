@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rowinf/blog-aggregator/internal/database"
+)
+
+// SchedulerConfig sizes the feed fetch worker pool.
+type SchedulerConfig struct {
+	// Workers caps how many feeds are fetched at once.
+	Workers int
+	// PerHost caps how many of those fetches may target the same hostname.
+	PerHost int
+	// PollInterval is how often the due-feed queue is checked when no
+	// worker has finished in the meantime.
+	PollInterval time.Duration
+}
+
+func schedulerConfigFromEnv() SchedulerConfig {
+	config := SchedulerConfig{
+		Workers:      10,
+		PerHost:      2,
+		PollInterval: 10 * time.Second,
+	}
+	if workers, err := strconv.Atoi(os.Getenv("FETCH_WORKERS")); err == nil && workers > 0 {
+		config.Workers = workers
+	}
+	if perHost, err := strconv.Atoi(os.Getenv("FETCH_PER_HOST")); err == nil && perHost > 0 {
+		config.PerHost = perHost
+	}
+	if interval, err := time.ParseDuration(os.Getenv("FETCH_POLL_INTERVAL")); err == nil && interval > 0 {
+		config.PollInterval = interval
+	}
+	return config
+}
+
+// feedScheduler continuously hands due feeds to a bounded pool of workers.
+// A feed is never fetched twice at once, and a slow host only ties up its
+// own PerHost slots instead of stalling everything else.
+type feedScheduler struct {
+	config  SchedulerConfig
+	due     func(ctx context.Context, limit int32) ([]database.Feed, error)
+	process func(ctx context.Context, feed database.Feed)
+
+	mu      sync.Mutex
+	running map[string]string
+	hosts   map[string]int
+	wake    chan struct{}
+}
+
+func newFeedScheduler(config SchedulerConfig, due func(context.Context, int32) ([]database.Feed, error), process func(context.Context, database.Feed)) *feedScheduler {
+	return &feedScheduler{
+		config:  config,
+		due:     due,
+		process: process,
+		running: make(map[string]string),
+		hosts:   make(map[string]int),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// run dispatches due feeds until ctx is cancelled, then waits for the
+// workers to return. ctx is passed on to every fetch so in-flight work is
+// cancelled too.
+func (s *feedScheduler) run(ctx context.Context) {
+	jobs := make(chan database.Feed)
+	var wg sync.WaitGroup
+	for i := 0; i < s.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for feed := range jobs {
+				s.process(ctx, feed)
+				s.release(feed)
+			}
+		}()
+	}
+	defer wg.Wait()
+	defer close(jobs)
+
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		s.dispatch(ctx, jobs)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// dispatch fills every idle worker from the due-feed queue, passing over
+// feeds that are already running or whose host is at its cap.
+func (s *feedScheduler) dispatch(ctx context.Context, jobs chan<- database.Feed) {
+	s.mu.Lock()
+	running := len(s.running)
+	s.mu.Unlock()
+	free := s.config.Workers - running
+	if free <= 0 {
+		return
+	}
+	// Running feeds are still due until they are marked fetched, and some
+	// candidates will be skipped for their host, so ask for extra.
+	feeds, err := s.due(ctx, int32(running+free*4))
+	if err != nil {
+		log.Printf("failed to load due feeds: %v", err)
+		return
+	}
+	for _, feed := range feeds {
+		if !s.claim(feed) {
+			continue
+		}
+		select {
+		case jobs <- feed:
+		case <-ctx.Done():
+			s.release(feed)
+			return
+		}
+		if free--; free == 0 {
+			return
+		}
+	}
+}
+
+func (s *feedScheduler) claim(feed database.Feed) bool {
+	host := feedHost(feed.Url)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.running[feed.ID]; ok {
+		return false
+	}
+	if len(s.running) >= s.config.Workers || s.hosts[host] >= s.config.PerHost {
+		return false
+	}
+	s.running[feed.ID] = host
+	s.hosts[host]++
+	return true
+}
+
+func (s *feedScheduler) release(feed database.Feed) {
+	s.mu.Lock()
+	host := s.running[feed.ID]
+	delete(s.running, feed.ID)
+	if s.hosts[host]--; s.hosts[host] <= 0 {
+		delete(s.hosts, host)
+	}
+	s.mu.Unlock()
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func feedHost(feedURL string) string {
+	parsed, err := url.Parse(feedURL)
+	if err != nil || parsed.Hostname() == "" {
+		return feedURL
+	}
+	return strings.ToLower(parsed.Hostname())
+}
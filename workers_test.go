@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rowinf/blog-aggregator/internal/database"
+)
+
+func TestFeedSchedulerLimits(t *testing.T) {
+	var feeds []database.Feed
+	for i := 0; i < 6; i++ {
+		feeds = append(feeds, database.Feed{ID: fmt.Sprintf("a%d", i), Url: fmt.Sprintf("https://A.example.com/%d.xml", i)})
+	}
+	for i := 0; i < 3; i++ {
+		feeds = append(feeds, database.Feed{ID: fmt.Sprintf("b%d", i), Url: fmt.Sprintf("https://b.example.com/%d.xml", i)})
+		feeds = append(feeds, database.Feed{ID: fmt.Sprintf("c%d", i), Url: fmt.Sprintf("https://c.example.com/%d.xml", i)})
+	}
+
+	var mu sync.Mutex
+	done := make(map[string]int)
+	active, maxActive := 0, 0
+	perHost, maxPerHost := make(map[string]int), 0
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	due := func(_ context.Context, limit int32) ([]database.Feed, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		var pending []database.Feed
+		for _, feed := range feeds {
+			if done[feed.ID] == 0 && int32(len(pending)) < limit {
+				pending = append(pending, feed)
+			}
+		}
+		return pending, nil
+	}
+	process := func(_ context.Context, feed database.Feed) {
+		host := feedHost(feed.Url)
+		mu.Lock()
+		active++
+		perHost[host]++
+		maxActive = max(maxActive, active)
+		maxPerHost = max(maxPerHost, perHost[host])
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		active--
+		perHost[host]--
+		done[feed.ID]++
+		if len(done) == len(feeds) {
+			cancel()
+		}
+		mu.Unlock()
+	}
+
+	scheduler := newFeedScheduler(SchedulerConfig{Workers: 3, PerHost: 1, PollInterval: time.Millisecond}, due, process)
+	finished := make(chan struct{})
+	go func() {
+		scheduler.run(ctx)
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduler did not drain the queue")
+	}
+
+	for _, feed := range feeds {
+		if done[feed.ID] != 1 {
+			t.Errorf("feed %s processed %d times, want 1", feed.ID, done[feed.ID])
+		}
+	}
+	if maxActive > 3 {
+		t.Errorf("%d feeds fetched at once, want at most 3", maxActive)
+	}
+	if maxPerHost > 1 {
+		t.Errorf("%d fetches against one host at once, want at most 1", maxPerHost)
+	}
+}
+
+func TestFeedHost(t *testing.T) {
+	if got := feedHost("https://Blog.Example.com:8443/feed.xml"); got != "blog.example.com" {
+		t.Errorf("feedHost = %q", got)
+	}
+}
+
+func TestFeedSchedulerDrainsOnCancel(t *testing.T) {
+	started := make(chan struct{})
+	var once sync.Once
+	var finished bool
+	due := func(context.Context, int32) ([]database.Feed, error) {
+		return []database.Feed{{ID: "slow", Url: "https://slow.example.com/feed.xml"}}, nil
+	}
+	process := func(ctx context.Context, feed database.Feed) {
+		once.Do(func() { close(started) })
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		finished = true
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	scheduler := newFeedScheduler(SchedulerConfig{Workers: 2, PerHost: 1, PollInterval: time.Millisecond}, due, process)
+	stopped := make(chan struct{})
+	go func() {
+		scheduler.run(ctx)
+		close(stopped)
+	}()
+
+	<-started
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduler did not stop after cancellation")
+	}
+	if !finished {
+		t.Error("run returned before the in-flight fetch finished")
+	}
+}